@@ -0,0 +1,118 @@
+package msgtypes
+
+import "math"
+
+// CompactOptions selects which base fields Compact derives.
+type CompactOptions struct {
+	BaseName  bool
+	BaseUnit  bool
+	BaseTime  bool
+	BaseValue bool
+	BaseSum   bool
+}
+
+// DefaultCompactOptions factors out names, units and times.
+var DefaultCompactOptions = CompactOptions{
+	BaseName: true,
+	BaseUnit: true,
+	BaseTime: true,
+}
+
+// Compact is the inverse of Normalize: it resolves p and then moves the
+// common name prefix, the dominant unit, the earliest time and optionally the
+// smallest value and sum into base fields on the first record. A base field
+// is only derived when every record resolves back to exactly the same value,
+// so Normalize(Compact(p, opts)) always equals Normalize(p). Packs that fail
+// validation are returned unchanged.
+func Compact(p Pack, opts CompactOptions) Pack {
+	n, err := Normalize(p)
+	if err != nil || len(n.Records) == 0 {
+		return p
+	}
+	records := n.Records
+
+	var bname, bunit string
+	var btime, bvalue, bsum float64
+
+	if opts.BaseName {
+		names := make([]string, len(records))
+		for i, r := range records {
+			names[i] = r.Name
+		}
+		bname = lcp(names)
+	}
+	if opts.BaseUnit {
+		units := make(map[Unit]int)
+		for _, r := range records {
+			if r.Unit == "" {
+				units = nil
+				break
+			}
+			units[Unit(r.Unit)]++
+		}
+		bunit = string(maxUnit(units))
+	}
+	if opts.BaseTime {
+		btime = baseOf(records, func(r *Record) *float64 { return &r.Time })
+	}
+	if opts.BaseValue && !hasDecimal(records) {
+		bvalue = baseOf(records, func(r *Record) *float64 { return r.Value })
+	}
+	if opts.BaseSum {
+		bsum = baseOf(records, func(r *Record) *float64 { return r.Sum })
+	}
+
+	for i, r := range records {
+		r.Name = r.Name[len(bname):]
+		if bunit != "" && r.Unit == bunit {
+			r.Unit = ""
+		}
+		r.Time -= btime
+		if r.Value != nil {
+			v := *r.Value - bvalue
+			r.Value = &v
+		}
+		if r.Sum != nil {
+			s := *r.Sum - bsum
+			r.Sum = &s
+		}
+		records[i] = r
+	}
+	records[0].BaseName = bname
+	records[0].BaseUnit = bunit
+	records[0].BaseTime = btime
+	records[0].BaseValue = bvalue
+	records[0].BaseSum = bsum
+
+	n.Records = records
+	return n
+}
+
+func hasDecimal(records []Record) bool {
+	for _, r := range records {
+		if r.DecimalValue != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// baseOf returns the smallest of the values selected by field, or zero when
+// subtracting it would not round-trip exactly for every record.
+func baseOf(records []Record, field func(r *Record) *float64) float64 {
+	base := math.Inf(1)
+	for i := range records {
+		if v := field(&records[i]); v != nil && *v < base {
+			base = *v
+		}
+	}
+	if math.IsInf(base, 0) || base == 0 {
+		return 0
+	}
+	for i := range records {
+		if v := field(&records[i]); v != nil && base+(*v-base) != *v {
+			return 0
+		}
+	}
+	return base
+}
@@ -0,0 +1,45 @@
+package msgtypes
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompact(t *testing.T) {
+	v1, v2, v3 := 20.5, 21.0, 0.1
+	s := 1200.0
+
+	p := Pack{Records: []Record{
+		{Name: "urn:dev:ow:10e2073a01080063:temp", Unit: "Cel", Time: 1.276020076e+09, Value: &v1},
+		{Name: "urn:dev:ow:10e2073a01080063:temp", Unit: "Cel", Time: 1.276020091e+09, Value: &v2},
+		{Name: "urn:dev:ow:10e2073a01080063:hum", Unit: "%RH", Time: 1.276020091e+09, Value: &v3},
+		{Name: "urn:dev:ow:10e2073a01080063:energy", Unit: "J", Time: 1.276020100e+09, Sum: &s},
+	}}
+
+	want, err := Normalize(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := Compact(p, CompactOptions{BaseName: true, BaseUnit: true, BaseTime: true, BaseValue: true, BaseSum: true})
+	if c.Records[0].BaseName != "urn:dev:ow:10e2073a01080063:" {
+		t.Fatalf("unexpected base name %q", c.Records[0].BaseName)
+	}
+	if c.Records[0].BaseUnit != "Cel" {
+		t.Fatalf("unexpected base unit %q", c.Records[0].BaseUnit)
+	}
+	if c.Records[0].BaseTime != 1.276020076e+09 {
+		t.Fatalf("unexpected base time %v", c.Records[0].BaseTime)
+	}
+
+	got, err := Normalize(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Normalize(Compact(p)) = %+v, want %+v", got, want)
+	}
+	if *p.Records[0].Value != v1 {
+		t.Fatal("Compact modified its input")
+	}
+}
@@ -0,0 +1,218 @@
+package msgtypes
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var ErrIncompatibleUnits = errors.New("incompatible units")
+
+// secondaryUnit describes a secondary unit as in the RFC 8798 registry: a
+// value v in the secondary unit is v*num/den + offset in the primary unit.
+type secondaryUnit struct {
+	primary  Unit
+	num, den int64
+	offset   float64
+}
+
+// secondaryUnits holds the built-in secondary units.
+var secondaryUnits = map[Unit]secondaryUnit{
+	Millisecond:            {Second, 1, 1000, 0},
+	Minute:                 {Second, 60, 1, 0},
+	Hour:                   {Second, 3600, 1, 0},
+	Megahertz:              {Hertz, 1000000, 1, 0},
+	Kilowatt:               {Watt, 1000, 1, 0},
+	KilovoltAmpere:         {VoltAmpere, 1000, 1, 0},
+	Kilovar:                {VoltAmpereReactive, 1000, 1, 0},
+	AmpereHour:             {Coulomb, 3600, 1, 0},
+	WattHour:               {Joule, 3600, 1, 0},
+	KilowattHour:           {Joule, 3600000, 1, 0},
+	VarHour:                {VoltAmpereReactiveSecond, 3600, 1, 0},
+	KilovarHour:            {VoltAmpereReactiveSecond, 3600000, 1, 0},
+	KilovoltAmpereHour:     {VoltAmpereSecond, 3600000, 1, 0},
+	WattHourPerKilometer:   {JoulePerMeter, 36, 10, 0},
+	Kibibyte:               {Byte, 1024, 1, 0},
+	Gigabyte:               {Byte, 1000000000, 1, 0},
+	MegabitPerSecond:       {BitPerSecond, 1000000, 1, 0},
+	BytePerSecond:          {BitPerSecond, 8, 1, 0},
+	MegabytePerSecond:      {BitPerSecond, 8000000, 1, 0},
+	Millivolt:              {Volt, 1, 1000, 0},
+	Milliampere:            {Ampere, 1, 1000, 0},
+	DecibelMilliwatt:       {DBW, 1, 1, -30},
+	MicrogramPerCubicMeter: {KilogramPerCubicMeter, 1, 1000000000, 0},
+	MillimeterPerHour:      {MeterPerSecond, 1, 3600000, 0},
+	MeterPerHour:           {MeterPerSecond, 1, 3600, 0},
+	PartsPerMillion:        {Ratio, 1, 1000000, 0},
+	Percent:                {Ratio, 1, 100, 0},
+	Permille:               {Ratio, 1, 1000, 0},
+	Hectopascal:            {Pascal, 100, 1, 0},
+	Millimeter:             {Meter, 1, 1000, 0},
+	Centimeter:             {Meter, 1, 100, 0},
+	Kilometer:              {Meter, 1000, 1, 0},
+	KilometerPerHour:       {MeterPerSecond, 10, 36, 0},
+	PartsPerBillion:        {Ratio, 1, 1000000000, 0},
+	PartsPerTrillion:       {Ratio, 1, 1000000000000, 0},
+	VoltAmpereHour:         {VoltAmpereSecond, 3600, 1, 0},
+	Milligram:              {KilogramPerCubicMeter, 1, 1000, 0},
+	Microgram:              {KilogramPerCubicMeter, 1, 1000000, 0},
+	GramPerLiter:           {KilogramPerCubicMeter, 1, 1, 0},
+}
+
+// Primary returns the primary unit of u in DefaultUnits together with the
+// scale and offset converting a value v in u into scale*v + offset in the
+// primary unit. Primary units and unknown units are their own primary unit.
+func (u Unit) Primary() (primary Unit, scale, offset float64) {
+	s := DefaultUnits.secondary(u)
+	return s.primary, float64(s.num) / float64(s.den), s.offset
+}
+
+func (s secondaryUnit) toPrimary(v float64) float64 {
+	return v*float64(s.num)/float64(s.den) + s.offset
+}
+
+func (s secondaryUnit) fromPrimary(v float64) float64 {
+	return (v - s.offset) * float64(s.den) / float64(s.num)
+}
+
+// Convert converts value from one unit of DefaultUnits to another sharing its
+// primary unit.
+func Convert(value float64, from, to Unit) (float64, error) {
+	return DefaultUnits.Convert(value, from, to)
+}
+
+// Convert converts value from one unit to another sharing its primary unit.
+func (g *UnitRegistry) Convert(value float64, from, to Unit) (float64, error) {
+	sf, st := g.secondary(from), g.secondary(to)
+	if sf.primary != st.primary {
+		return 0, fmt.Errorf("%w: %s and %s", ErrIncompatibleUnits, from, to)
+	}
+	if from == to {
+		return value, nil
+	}
+	return st.fromPrimary(sf.toPrimary(value)), nil
+}
+
+// UnitWarning reports a record using a unit that is not recommended.
+// Replacement is the unit its values were converted to, if any.
+type UnitWarning struct {
+	Index       int
+	Name        string
+	Unit        Unit
+	Replacement Unit
+}
+
+func (w UnitWarning) String() string {
+	if w.Replacement == "" {
+		return fmt.Sprintf("record %d (%q): unit %q is not recommended", w.Index, w.Name, w.Unit)
+	}
+	return fmt.Sprintf("record %d (%q): unit %q is not recommended, converted to %q", w.Index, w.Name, w.Unit, w.Replacement)
+}
+
+// toPrimaryUnit rewrites the values of a resolved record into the primary
+// unit. DecimalValue is kept only when the conversion is exact.
+func (r *Record) toPrimaryUnit(g *UnitRegistry) {
+	s := g.secondary(Unit(r.Unit))
+	if s.primary == Unit(r.Unit) {
+		return
+	}
+	r.rescale(s.primary, s.toPrimary, s.convertDecimal)
+}
+
+// toRecommendedUnit rewrites the values of a resolved record in a unit that
+// is not recommended into its replacement. It reports whether the unit is
+// not recommended.
+func (r *Record) toRecommendedUnit(g *UnitRegistry) (replacement Unit, deprecated bool) {
+	info, ok := g.Lookup(Unit(r.Unit))
+	if !ok || info.Recommended {
+		return None, false
+	}
+	if info.Replacement == "" {
+		return None, true
+	}
+	scale, num, den := info.ReplacementScale, info.ReplacementNum, info.ReplacementDen
+	r.rescale(info.Replacement, func(v float64) float64 { return v * scale }, func(d Decimal) (Decimal, error) {
+		if den == 0 {
+			return Decimal{}, ErrNumericInexact
+		}
+		return mulRat(d, big.NewRat(num, den))
+	})
+	return info.Replacement, true
+}
+
+// rescale converts the values of r with conv and sets its unit to u. The
+// decimal value falls back to a float when dec fails.
+func (r *Record) rescale(u Unit, conv func(float64) float64, dec func(Decimal) (Decimal, error)) {
+	if r.DecimalValue != nil {
+		d, err := dec(*r.DecimalValue)
+		if err != nil {
+			r.Value = r.floatValue()
+			r.DecimalValue = nil
+		} else {
+			r.DecimalValue = &d
+		}
+	}
+	if r.Value != nil {
+		v := conv(*r.Value)
+		r.Value = &v
+	}
+	if r.Sum != nil {
+		v := conv(*r.Sum)
+		r.Sum = &v
+	}
+	if r.VectorValue != nil {
+		vv := make([]float64, len(*r.VectorValue))
+		for i, v := range *r.VectorValue {
+			vv[i] = conv(v)
+		}
+		r.VectorValue = &vv
+	}
+	r.Unit = string(u)
+}
+
+// convertDecimal converts d exactly, which is possible when the scale has a
+// finite decimal expansion.
+func (s secondaryUnit) convertDecimal(d Decimal) (Decimal, error) {
+	d, err := mulRat(d, big.NewRat(s.num, s.den))
+	if err != nil || s.offset == 0 {
+		return d, err
+	}
+	off, err := NumericToDecimal(s.offset)
+	if err != nil {
+		return Decimal{}, err
+	}
+	return d.Add(off)
+}
+
+// mulRat returns d * x exactly, which is possible when the product reduces to
+// a fraction whose denominator is 2**a * 5**b.
+func mulRat(d Decimal, x *big.Rat) (Decimal, error) {
+	m := new(big.Int).Mul(x.Num(), big.NewInt(int64(d[1])))
+	den := new(big.Int).Set(x.Denom())
+	if g := new(big.Int).GCD(nil, nil, m, den); g.Sign() > 0 {
+		m.Quo(m, g)
+		den.Quo(den, g)
+	}
+	a := int(den.TrailingZeroBits())
+	den.Rsh(den, uint(a))
+	b, five, q, r := 0, big.NewInt(5), new(big.Int), new(big.Int)
+	for q.QuoRem(den, five, r); r.Sign() == 0; q.QuoRem(den, five, r) {
+		den, q = q, den
+		b++
+	}
+	if !den.IsInt64() || den.Int64() != 1 {
+		return Decimal{}, ErrNumericInexact
+	}
+	// m / (2**a * 5**b) = m * 2**(k-a) * 5**(k-b) / 10**k.
+	k := a
+	if b > k {
+		k = b
+	}
+	m.Lsh(m, uint(k-a))
+	m.Mul(m, new(big.Int).Exp(five, big.NewInt(int64(k-b)), nil))
+	exp := int64(d[0]) - int64(k)
+	if exp != int64(int(exp)) {
+		return Decimal{}, ErrDecimalOverflow
+	}
+	return decimalFromBig(m, int(exp))
+}
@@ -0,0 +1,140 @@
+package msgtypes
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	for _, c := range []struct {
+		v        float64
+		from, to Unit
+		want     float64
+	}{
+		{1.5, KilowattHour, WattHour, 1500},
+		{2, KilowattHour, Joule, 7.2e6},
+		{36, KilometerPerHour, MeterPerSecond, 10},
+		{0, DecibelMilliwatt, DBW, -30},
+		{250, Millisecond, Minute, 250.0 / 60000},
+		{5, Percent, PartsPerMillion, 50000},
+	} {
+		got, err := Convert(c.v, c.from, c.to)
+		if err != nil || math.Abs(got-c.want) > 1e-9*math.Abs(c.want) {
+			t.Errorf("Convert(%v, %s, %s) = %v %v, want %v", c.v, c.from, c.to, got, err, c.want)
+		}
+	}
+	if _, err := Convert(1, WattHour, Watt); !errors.Is(err, ErrIncompatibleUnits) {
+		t.Fatalf("got %v, want %v", err, ErrIncompatibleUnits)
+	}
+	if u, scale, offset := Hectopascal.Primary(); u != Pascal || scale != 100 || offset != 0 {
+		t.Fatalf("unexpected primary %s %v %v", u, scale, offset)
+	}
+}
+
+func TestNormalizePrimaryUnits(t *testing.T) {
+	v := 1.5
+	d := NewDecimal(-2, 1234)
+	p := Pack{Records: []Record{
+		{Name: "a", Unit: string(KilowattHour), Value: &v},
+		{Name: "b", Unit: string(KilowattHour), DecimalValue: &d},
+		{Name: "c", Unit: string(Celsius), Value: &v},
+	}}
+
+	n, err := NormalizeOptions{PrimaryUnits: true}.Normalize(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.Records[0].Unit != string(Joule) || *n.Records[0].Value != 5.4e6 {
+		t.Fatalf("unexpected record %+v", n.Records[0])
+	}
+	if n.Records[1].DecimalValue == nil || n.Records[1].DecimalValue.Cmp(NewDecimal(0, 44424000)) != 0 {
+		t.Fatalf("unexpected decimal %v", n.Records[1].DecimalValue)
+	}
+	if n.Records[2].Unit != string(Celsius) || *n.Records[2].Value != v {
+		t.Fatalf("unexpected record %+v", n.Records[2])
+	}
+	if v != 1.5 {
+		t.Fatal("Normalize modified its input")
+	}
+}
+
+func TestRecommendedUnits(t *testing.T) {
+	if Ratio2.Recommended() || Gram.Recommended() || !Kilogram.Recommended() || Unit("furlong").Recommended() {
+		t.Fatal("unexpected recommended status")
+	}
+
+	v, w := 45.0, 1500.0
+	d := NewDecimal(0, 250)
+	p := Pack{Records: []Record{
+		{BaseName: "dev/", BaseUnit: string(Ratio2), Name: "load", Value: &v},
+		{Name: "mass", Unit: string(Gram), DecimalValue: &d},
+		{Name: "power", Unit: string(Watt), Value: &w},
+	}}
+	var warnings []UnitWarning
+	n, err := NormalizeOptions{RecommendedUnits: true, Warn: func(w UnitWarning) { warnings = append(warnings, w) }}.Normalize(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := n.Records[0]; r.Unit != string(Ratio) || math.Abs(*r.Value-0.45) > 1e-12 {
+		t.Fatalf("unexpected %+v", r)
+	}
+	if r := n.Records[1]; r.Unit != string(Kilogram) || r.DecimalValue.Cmp(NewDecimal(-2, 25)) != 0 {
+		t.Fatalf("unexpected %+v", r)
+	}
+	if len(warnings) != 2 || warnings[1].Name != "dev/mass" || warnings[1].Replacement != Kilogram {
+		t.Fatalf("unexpected warnings %v", warnings)
+	}
+
+	b, err := EncodeOptions{RecommendedUnits: true}.Encode(p, JSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(b), `[{"n":"dev/load","u":"/","v":0.45},{"n":"dev/mass","u":"kg","v":0.25},{"n":"dev/power","u":"W","v":1500}]`; got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
+
+func TestRecommendedUnitsDecimal(t *testing.T) {
+	cases := []struct {
+		unit Unit
+		d    Decimal
+		want *Decimal
+		f    float64
+	}{
+		{Gram, NewDecimal(-2, 1234), &Decimal{-5, 1234}, 0.01234},
+		{HeartRate, NewDecimal(0, 90), &Decimal{-1, 15}, 1.5},
+		// 1/60 and pi/180 have no finite decimal expansion.
+		{RPM, NewDecimal(0, 1), nil, 1.0 / 60},
+		{Degree, NewDecimal(0, 180), nil, math.Pi},
+	}
+	for _, c := range cases {
+		d := c.d
+		p := Pack{Records: []Record{{Name: "x", Unit: string(c.unit), DecimalValue: &d}}}
+		n, err := NormalizeOptions{RecommendedUnits: true}.Normalize(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := n.Records[0]
+		if c.want == nil {
+			if r.DecimalValue != nil || r.Value == nil || math.Abs(*r.Value-c.f) > 1e-12 {
+				t.Errorf("%s: unexpected %+v", c.unit, r)
+			}
+			continue
+		}
+		if r.DecimalValue == nil || r.DecimalValue.Cmp(*c.want) != 0 {
+			t.Errorf("%s: unexpected %+v", c.unit, r)
+		}
+	}
+
+	g := NewUnitRegistry()
+	if err := g.Register(UnitInfo{Symbol: "x", Replacement: Kilogram, ReplacementNum: 1}); err == nil {
+		t.Fatal("registered a replacement scale without a denominator")
+	}
+	if err := g.Register(UnitInfo{Symbol: "x", Replacement: Kilogram, ReplacementNum: 1, ReplacementDen: 4}); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := g.Lookup("x"); info.ReplacementScale != 0.25 {
+		t.Fatalf("scale %v", info.ReplacementScale)
+	}
+}
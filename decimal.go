@@ -1,12 +1,28 @@
 package msgtypes
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"math"
+	"math/big"
+	"strconv"
+	"strings"
 )
 
+// Decimal is an exact number, value * 10**exponent, stored as
+// {exponent, value} like the CBOR decimal fraction of RFC 8949 section 3.4.4.
+// Arithmetic is carried out with math/big, so intermediate results are
+// exact for any exponent; results that do not fit the pair of ints fail
+// with ErrDecimalOverflow instead of wrapping around.
 type Decimal [2]int
 
+var (
+	ErrBadDecimal      = errors.New("invalid decimal fraction")
+	ErrDecimalOverflow = errors.New("decimal overflow")
+	ErrDecimalInexact  = errors.New("decimal has a fractional part")
+)
+
 func NewDecimal(exponent, value int) Decimal {
 	return Decimal{exponent, value}
 }
@@ -15,10 +31,13 @@ func (n Decimal) slice() []int {
 	return n[:]
 }
 
+// Float returns the float64 closest to n.
 func (n Decimal) Float() float64 {
-	return float64(n[1]) * math.Pow10(n[0])
+	f, _ := strconv.ParseFloat(strconv.Itoa(n[1])+"e"+strconv.Itoa(n[0]), 64)
+	return f
 }
 
+// Int returns n truncated toward zero. See Int64 for a checked conversion.
 func (n Decimal) Int() int {
 	if n[0] < 0 {
 		return n[1] / pow10(-n[0])
@@ -26,6 +45,348 @@ func (n Decimal) Int() int {
 	return n[1] * pow10(n[0])
 }
 
+// Int64 returns n as an int64. It fails with ErrDecimalInexact if n has a
+// fractional part and with ErrDecimalOverflow if n does not fit.
+func (n Decimal) Int64() (int64, error) {
+	n = n.Normalize()
+	if n[0] < 0 {
+		return 0, ErrDecimalInexact
+	}
+	if n[0] > 19 {
+		return 0, ErrDecimalOverflow
+	}
+	x := new(big.Int).Mul(big.NewInt(int64(n[1])), bigPow10(n[0]))
+	if !x.IsInt64() {
+		return 0, ErrDecimalOverflow
+	}
+	return x.Int64(), nil
+}
+
+// Exponent returns the power of ten n is scaled by.
+func (n Decimal) Exponent() int {
+	return n[0]
+}
+
+// Mantissa returns the unscaled value of n.
+func (n Decimal) Mantissa() int {
+	return n[1]
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of n.
+func (n Decimal) Sign() int {
+	switch {
+	case n[1] < 0:
+		return -1
+	case n[1] > 0:
+		return 1
+	}
+	return 0
+}
+
+// Normalize removes trailing zeros from the mantissa of n, so that equal
+// numbers have equal representations. Zero normalizes to {0, 0}.
+func (n Decimal) Normalize() Decimal {
+	if n[1] == 0 {
+		return Decimal{}
+	}
+	for n[1]%10 == 0 && n[0] < math.MaxInt {
+		n[1] /= 10
+		n[0]++
+	}
+	return n
+}
+
+// Add returns n + m.
+func (n Decimal) Add(m Decimal) (Decimal, error) {
+	x, y, exp, err := align(n, m)
+	if err != nil {
+		return Decimal{}, err
+	}
+	return decimalFromBig(x.Add(x, y), exp)
+}
+
+// Sub returns n - m.
+func (n Decimal) Sub(m Decimal) (Decimal, error) {
+	x, y, exp, err := align(n, m)
+	if err != nil {
+		return Decimal{}, err
+	}
+	return decimalFromBig(x.Sub(x, y), exp)
+}
+
+// Mul returns n * m.
+func (n Decimal) Mul(m Decimal) (Decimal, error) {
+	exp := int64(n[0]) + int64(m[0])
+	if exp != int64(int(exp)) {
+		return Decimal{}, ErrDecimalOverflow
+	}
+	x := new(big.Int).Mul(big.NewInt(int64(n[1])), big.NewInt(int64(m[1])))
+	return decimalFromBig(x, int(exp))
+}
+
+// Cmp compares n and m and returns -1, 0 or +1.
+func (n Decimal) Cmp(m Decimal) int {
+	n, m = n.Normalize(), m.Normalize()
+	if sn, sm := n.Sign(), m.Sign(); sn != sm || sn == 0 {
+		switch {
+		case sn < sm:
+			return -1
+		case sn > sm:
+			return 1
+		}
+		return 0
+	}
+	// Compare the position of the most significant digit first, so that
+	// aligning the exponents never needs more than a few digits. The
+	// positions are computed with big.Int as they may overflow an int.
+	an := big.NewInt(int64(n[0]))
+	an.Add(an, big.NewInt(int64(digits(n[1]))))
+	am := big.NewInt(int64(m[0]))
+	am.Add(am, big.NewInt(int64(digits(m[1]))))
+	if c := an.Cmp(am); c != 0 {
+		if (c < 0) == (n.Sign() > 0) {
+			return -1
+		}
+		return 1
+	}
+	x, y, _, _ := align(n, m)
+	return x.Cmp(y)
+}
+
+// Round rounds n to the given number of digits after the decimal point.
+func (n Decimal) Round(places int, mode big.RoundingMode) (Decimal, error) {
+	// shift is -places - n[0], computed without overflowing an int.
+	s := big.NewInt(int64(places))
+	s.Neg(s).Sub(s, big.NewInt(int64(n[0])))
+	if s.Sign() <= 0 {
+		return n, nil
+	}
+	if places == math.MinInt {
+		return Decimal{}, ErrDecimalOverflow
+	}
+	sign := int64(n.Sign())
+	var q, r *big.Int
+	var half int
+	if !s.IsInt64() || s.Int64() > 20 {
+		// |mantissa| < 10**19, so the quotient is zero and the remainder is
+		// less than half of the divisor.
+		q, r = new(big.Int), big.NewInt(int64(n[1]))
+		half = -1
+	} else {
+		d := bigPow10(int(s.Int64()))
+		q, r = new(big.Int).QuoRem(big.NewInt(int64(n[1])), d, new(big.Int))
+		half = new(big.Int).Abs(new(big.Int).Lsh(r, 1)).Cmp(d)
+	}
+	if r.Sign() != 0 {
+		var up bool
+		switch mode {
+		case big.ToZero:
+		case big.AwayFromZero:
+			up = true
+		case big.ToNegativeInf:
+			up = sign < 0
+		case big.ToPositiveInf:
+			up = sign > 0
+		case big.ToNearestAway:
+			up = half >= 0
+		case big.ToNearestEven:
+			up = half > 0 || half == 0 && q.Bit(0) == 1
+		}
+		if up {
+			q.Add(q, big.NewInt(sign))
+		}
+	}
+	return decimalFromBig(q, -places)
+}
+
+// ParseDecimal parses a decimal number such as "12.34", "-5" or "1.5e-3".
+func ParseDecimal(s string) (Decimal, error) {
+	mant, exp := s, 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.Atoi(s[i+1:])
+		if err != nil {
+			return Decimal{}, fmt.Errorf("%w: %q", ErrBadDecimal, s)
+		}
+		mant, exp = s[:i], e
+	}
+	if i := strings.IndexByte(mant, '.'); i >= 0 {
+		exp -= len(mant) - i - 1
+		mant = mant[:i] + mant[i+1:]
+	}
+	digits := strings.TrimLeft(mant, "+-")
+	if len(mant)-len(digits) > 1 || digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrBadDecimal, s)
+	}
+	x, ok := new(big.Int).SetString(mant, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrBadDecimal, s)
+	}
+	return decimalFromBig(x, exp)
+}
+
+// String formats n in plain notation, or in exponent notation when that
+// would need more than 20 padding zeros.
+func (n Decimal) String() string {
+	m := strconv.Itoa(n[1])
+	sign := ""
+	if n[1] < 0 {
+		sign, m = "-", m[1:]
+	}
+	switch {
+	case n[0] == 0 || n[1] == 0:
+		return sign + m
+	case n[0] > 0 && n[0] <= 20:
+		return sign + m + strings.Repeat("0", n[0])
+	case n[0] < 0 && n[0] > -len(m):
+		i := len(m) + n[0]
+		return sign + m[:i] + "." + m[i:]
+	case n[0] < 0 && n[0] >= -len(m)-20:
+		return sign + "0." + strings.Repeat("0", -n[0]-len(m)) + m
+	}
+	return sign + m + "e" + strconv.Itoa(n[0])
+}
+
+func (n Decimal) MarshalText() ([]byte, error) {
+	return []byte(n.String()), nil
+}
+
+func (n *Decimal) UnmarshalText(text []byte) error {
+	d, err := ParseDecimal(string(text))
+	if err != nil {
+		return err
+	}
+	*n = d
+	return nil
+}
+
+// MarshalJSON encodes n as a JSON number.
+func (n Decimal) MarshalJSON() ([]byte, error) {
+	return n.MarshalText()
+}
+
+// UnmarshalJSON accepts a JSON number or a string holding a number.
+func (n *Decimal) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	return n.UnmarshalText(bytes.Trim(data, `"`))
+}
+
+// align returns the mantissas of n and m scaled to their common exponent.
+func align(n, m Decimal) (*big.Int, *big.Int, int, error) {
+	if n[1] == 0 {
+		n[0] = m[0]
+	}
+	if m[1] == 0 {
+		m[0] = n[0]
+	}
+	exp := n[0]
+	if m[0] < exp {
+		exp = m[0]
+	}
+	// A non-zero mantissa scaled by more than 10**40 can not fit an int,
+	// whatever the other operand is. Both exponents are at least exp, so
+	// their unsigned differences are exact even where n[0]-exp overflows.
+	dn, dm := uint64(n[0])-uint64(exp), uint64(m[0])-uint64(exp)
+	if dn > 40 || dm > 40 {
+		return nil, nil, 0, ErrDecimalOverflow
+	}
+	x := new(big.Int).Mul(big.NewInt(int64(n[1])), bigPow10(int(dn)))
+	y := new(big.Int).Mul(big.NewInt(int64(m[1])), bigPow10(int(dm)))
+	return x, y, exp, nil
+}
+
+// decimalFromBig returns x * 10**exp as a Decimal, dropping trailing zeros
+// from x when it does not fit an int.
+func decimalFromBig(x *big.Int, exp int) (Decimal, error) {
+	ten, r := big.NewInt(10), new(big.Int)
+	for !fitsInt(x) {
+		q, _ := new(big.Int).QuoRem(x, ten, r)
+		if r.Sign() != 0 || exp == math.MaxInt {
+			return Decimal{}, ErrDecimalOverflow
+		}
+		x = q
+		exp++
+	}
+	return Decimal{exp, int(x.Int64())}, nil
+}
+
+func fitsInt(x *big.Int) bool {
+	return x.IsInt64() && int64(int(x.Int64())) == x.Int64()
+}
+
+func bigPow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+func digits(v int) int {
+	u := uint64(v)
+	if v < 0 {
+		u = -u
+	}
+	n := 1
+	for u >= 10 {
+		u /= 10
+		n++
+	}
+	return n
+}
+
+// appendCBOR appends n encoded as a CBOR decimal fraction (tag 4).
+func (n Decimal) appendCBOR(b []byte) []byte {
+	b = append(b, 0xc4, 0x82)
+	for _, v := range n {
+		if v < 0 {
+			b = appendCBORHead(b, 1, uint64(-(v + 1)))
+		} else {
+			b = appendCBORHead(b, 0, uint64(v))
+		}
+	}
+	return b
+}
+
+func appendCBORHead(b []byte, major byte, v uint64) []byte {
+	major <<= 5
+	switch {
+	case v < 24:
+		return append(b, major|byte(v))
+	case v <= math.MaxUint8:
+		return append(b, major|24, byte(v))
+	case v <= math.MaxUint16:
+		return append(b, major|25, byte(v>>8), byte(v))
+	case v <= math.MaxUint32:
+		return append(b, major|26, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+	}
+	return append(b, major|27, byte(v>>56), byte(v>>48), byte(v>>40), byte(v>>32),
+		byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+// decimalFromCBOR converts the [exponent, mantissa] array of a decoded CBOR
+// decimal fraction.
+func decimalFromCBOR(a []interface{}) (Decimal, error) {
+	var n Decimal
+	if len(a) != 2 {
+		return n, ErrBadDecimal
+	}
+	for i, v := range a {
+		switch v := v.(type) {
+		case uint64:
+			if v > math.MaxInt64 || int64(int(v)) != int64(v) {
+				return n, ErrBadDecimal
+			}
+			n[i] = int(v)
+		case int64:
+			if int64(int(v)) != v {
+				return n, ErrBadDecimal
+			}
+			n[i] = int(v)
+		default:
+			return n, ErrBadDecimal
+		}
+	}
+	return n, nil
+}
+
 type decimalType struct{}
 
 func (t *decimalType) ConvertExt(v interface{}) interface{} {
@@ -0,0 +1,161 @@
+package msgtypes
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+	"testing"
+)
+
+func TestDecimalCBOR(t *testing.T) {
+	d := NewDecimal(-2, 1234)
+	p := Pack{Records: []Record{{Name: "energy", Unit: "kWh", DecimalValue: &d}}}
+
+	data, err := Encode(p, CBOR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2: 4([-2, 1234])
+	if !bytes.Contains(data, []byte{0x02, 0xc4, 0x82, 0x21, 0x19, 0x04, 0xd2}) {
+		t.Fatalf("decimal fraction not found in %x", data)
+	}
+
+	p2, err := Decode(data, CBOR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := p2.Records[0]
+	if r.DecimalValue == nil || *r.DecimalValue != d {
+		t.Fatalf("got decimal %v, want %v", r.DecimalValue, d)
+	}
+	if r.Value == nil || *r.Value != 12.34 {
+		t.Fatalf("got value %v, want 12.34", r.Value)
+	}
+
+	data, err = Encode(p, JSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `[{"n":"energy","u":"kWh","v":12.34}]` {
+		t.Fatalf("unexpected JSON %s", data)
+	}
+}
+
+func TestDecimalNormalize(t *testing.T) {
+	v := 1.0
+	d := NewDecimal(-2, 1234)
+	p := Pack{Records: []Record{{BaseValue: 10, Name: "a", Value: &v}, {Name: "b", DecimalValue: &d}}}
+
+	n, err := Normalize(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := n.Records[1]
+	if r.Kind() != FloatKind || r.DecimalValue == nil || *r.DecimalValue != NewDecimal(-2, 2234) {
+		t.Fatalf("got %+v, want decimal 22.34", r)
+	}
+	if _, err := Normalize(Compact(n, CompactOptions{BaseValue: true})); err != nil {
+		t.Fatal(err)
+	}
+
+	b := true
+	p = Pack{Records: []Record{{BaseName: "x", DecimalValue: &d, BoolValue: &b}}}
+	if err := (ValidationOptions{AllowBaseOnly: true}).Validate(p); !errors.Is(err, ErrTooManyValues) {
+		t.Fatalf("got %v, want %v", err, ErrTooManyValues)
+	}
+}
+
+func TestDecimalExtremeExponents(t *testing.T) {
+	for _, c := range []struct {
+		n, m Decimal
+		want int
+	}{
+		{NewDecimal(math.MaxInt, 1), NewDecimal(math.MaxInt-1, 1), 1},
+		{NewDecimal(math.MaxInt, 1), NewDecimal(math.MaxInt-1, 99), -1},
+		{NewDecimal(math.MaxInt, -1), NewDecimal(math.MaxInt-1, -1), -1},
+		{NewDecimal(math.MinInt, 5), NewDecimal(math.MinInt+1, 1), -1},
+		{NewDecimal(math.MaxInt, 1), NewDecimal(math.MinInt, 1), 1},
+		{NewDecimal(math.MinInt, math.MinInt), NewDecimal(math.MinInt, -1), -1},
+	} {
+		if got := c.n.Cmp(c.m); got != c.want {
+			t.Errorf("%v.Cmp(%v) = %d, want %d", c.n, c.m, got, c.want)
+		}
+		if got := c.m.Cmp(c.n); got != -c.want {
+			t.Errorf("%v.Cmp(%v) = %d, want %d", c.m, c.n, got, -c.want)
+		}
+	}
+
+	if _, err := NewDecimal(math.MaxInt, 1).Add(NewDecimal(math.MinInt, 1)); !errors.Is(err, ErrDecimalOverflow) {
+		t.Fatalf("got %v, want %v", err, ErrDecimalOverflow)
+	}
+	if _, err := NewDecimal(math.MaxInt, 5).Round(math.MinInt, big.ToZero); !errors.Is(err, ErrDecimalOverflow) {
+		t.Fatalf("got %v, want %v", err, ErrDecimalOverflow)
+	}
+	if s := NewDecimal(math.MinInt, 5).String(); s != "5e"+strconv.Itoa(math.MinInt) {
+		t.Fatalf("got %s", s)
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	a, err := ParseDecimal("12.34")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := ParseDecimal("-0.005")
+
+	sum, err := a.Add(b)
+	if err != nil || sum.String() != "12.335" {
+		t.Fatalf("got %v %v, want 12.335", sum, err)
+	}
+	diff, _ := a.Sub(b)
+	if diff.String() != "12.345" {
+		t.Fatalf("got %v, want 12.345", diff)
+	}
+	prod, _ := a.Mul(NewDecimal(3, 2))
+	if prod.Cmp(NewDecimal(0, 24680)) != 0 {
+		t.Fatalf("got %v, want 24680", prod)
+	}
+	if a.Cmp(b) != 1 || b.Cmp(a) != -1 || NewDecimal(1, 5).Cmp(NewDecimal(0, 50)) != 0 {
+		t.Fatal("unexpected comparison result")
+	}
+
+	for _, c := range []struct {
+		in   string
+		mode big.RoundingMode
+		want string
+	}{
+		{"12.345", big.ToNearestEven, "12.34"},
+		{"12.355", big.ToNearestEven, "12.36"},
+		{"12.345", big.ToNearestAway, "12.35"},
+		{"-12.341", big.ToNegativeInf, "-12.35"},
+		{"-12.349", big.ToZero, "-12.34"},
+		{"12.341", big.AwayFromZero, "12.35"},
+	} {
+		d, _ := ParseDecimal(c.in)
+		r, err := d.Round(2, c.mode)
+		if err != nil || r.String() != c.want {
+			t.Fatalf("Round(%s, %v) = %v %v, want %s", c.in, c.mode, r, err, c.want)
+		}
+	}
+
+	if _, err := NewDecimal(0, math.MaxInt64).Add(NewDecimal(0, 1)); !errors.Is(err, ErrDecimalOverflow) {
+		t.Fatalf("got %v, want %v", err, ErrDecimalOverflow)
+	}
+	if _, err := a.Int64(); !errors.Is(err, ErrDecimalInexact) {
+		t.Fatalf("got %v, want %v", err, ErrDecimalInexact)
+	}
+	if _, err := ParseDecimal("1.2.3"); !errors.Is(err, ErrBadDecimal) {
+		t.Fatalf("got %v, want %v", err, ErrBadDecimal)
+	}
+
+	var v struct{ D Decimal }
+	if err := json.Unmarshal([]byte(`{"D":0.00012}`), &v); err != nil || v.D != NewDecimal(-5, 12) {
+		t.Fatalf("got %v %v", v.D, err)
+	}
+	if b, _ := json.Marshal(v); string(b) != `{"D":0.00012}` {
+		t.Fatalf("got %s", b)
+	}
+}
@@ -0,0 +1,228 @@
+package msgtypes
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrUnknownUnit = errors.New("unknown unit")
+
+// Dimension is the exponent vector of a unit over the SI base quantities
+// length, mass, time, electric current, temperature, amount of substance
+// and luminous intensity.
+type Dimension [7]int8
+
+const (
+	LengthDim = iota
+	MassDim
+	TimeDim
+	CurrentDim
+	TemperatureDim
+	AmountDim
+	LuminosityDim
+)
+
+// Dimensionless is the dimension of ratios, counts, angles and levels.
+var Dimensionless Dimension
+
+func dim(l, m, t, i, th, n, j int8) Dimension {
+	return Dimension{l, m, t, i, th, n, j}
+}
+
+var (
+	lengthDim       = dim(1, 0, 0, 0, 0, 0, 0)
+	massDim         = dim(0, 1, 0, 0, 0, 0, 0)
+	timeDim         = dim(0, 0, 1, 0, 0, 0, 0)
+	frequencyDim    = dim(0, 0, -1, 0, 0, 0, 0)
+	forceDim        = dim(1, 1, -2, 0, 0, 0, 0)
+	pressureDim     = dim(-1, 1, -2, 0, 0, 0, 0)
+	energyDim       = dim(2, 1, -2, 0, 0, 0, 0)
+	powerDim        = dim(2, 1, -3, 0, 0, 0, 0)
+	chargeDim       = dim(0, 0, 1, 1, 0, 0, 0)
+	voltageDim      = dim(2, 1, -3, -1, 0, 0, 0)
+	conductanceDim  = dim(-2, -1, 3, 2, 0, 0, 0)
+	doseDim         = dim(2, 0, -2, 0, 0, 0, 0)
+	volumeDim       = dim(3, 0, 0, 0, 0, 0, 0)
+	velocityDim     = dim(1, 0, -1, 0, 0, 0, 0)
+	flowDim         = dim(3, 0, -1, 0, 0, 0, 0)
+	luminanceDim    = dim(-2, 0, 0, 0, 0, 0, 1)
+	densityDim      = dim(-3, 1, 0, 0, 0, 0, 0)
+	temperatureDim  = dim(0, 0, 0, 0, 1, 0, 0)
+	energyLengthDim = dim(1, 1, -2, 0, 0, 0, 0)
+)
+
+// unitDimensions holds the dimensions of the built-in primary units.
+var unitDimensions = map[Unit]Dimension{
+	None:                    Dimensionless,
+	Meter:                   lengthDim,
+	Kilogram:                massDim,
+	Gram:                    massDim,
+	Second:                  timeDim,
+	Ampere:                  dim(0, 0, 0, 1, 0, 0, 0),
+	Kelvin:                  temperatureDim,
+	Candela:                 dim(0, 0, 0, 0, 0, 0, 1),
+	Mole:                    dim(0, 0, 0, 0, 0, 1, 0),
+	Hertz:                   frequencyDim,
+	Radian:                  Dimensionless,
+	Steradian:               Dimensionless,
+	Newton:                  forceDim,
+	Pascal:                  pressureDim,
+	Joule:                   energyDim,
+	Watt:                    powerDim,
+	Coulomb:                 chargeDim,
+	Volt:                    voltageDim,
+	Farad:                   dim(-2, -1, 4, 2, 0, 0, 0),
+	Ohm:                     dim(2, 1, -3, -2, 0, 0, 0),
+	Siemens:                 conductanceDim,
+	Weber:                   dim(2, 1, -2, -1, 0, 0, 0),
+	Tesla:                   dim(0, 1, -2, -1, 0, 0, 0),
+	Henry:                   dim(2, 1, -2, -2, 0, 0, 0),
+	Celsius:                 temperatureDim,
+	Lumen:                   dim(0, 0, 0, 0, 0, 0, 1),
+	Lux:                     luminanceDim,
+	Becquerel:               frequencyDim,
+	Gray:                    doseDim,
+	Sievert:                 doseDim,
+	Katal:                   dim(0, 0, -1, 0, 0, 1, 0),
+	SquareMeter:             dim(2, 0, 0, 0, 0, 0, 0),
+	CubicMeter:              volumeDim,
+	Liter:                   volumeDim,
+	MeterPerSecond:          velocityDim,
+	MeterPerSquareSecond:    dim(1, 0, -2, 0, 0, 0, 0),
+	CubicMeterPerSecond:     flowDim,
+	LiterPerSecond:          flowDim,
+	WattPerSquareMeter:      dim(0, 1, -3, 0, 0, 0, 0),
+	CandelaPerSquareMeter:   luminanceDim,
+	Bit:                     Dimensionless,
+	BitPerSecond:            frequencyDim,
+	Latitude:                Dimensionless,
+	Longitude:               Dimensionless,
+	PH:                      Dimensionless,
+	Decibel:                 Dimensionless,
+	DBW:                     Dimensionless,
+	Bel:                     Dimensionless,
+	Count:                   Dimensionless,
+	Ratio:                   Dimensionless,
+	Ratio2:                  Dimensionless,
+	RelativeHumidityPercent: Dimensionless,
+	RemainingBatteryPercent: Dimensionless,
+	RemainingBatterySeconds: timeDim,
+	Rate:                    frequencyDim,
+	RPM:                     frequencyDim,
+	HeartRate:               frequencyDim,
+	HeartBeats:              Dimensionless,
+	Conductivity:            dim(-3, -1, 3, 2, 0, 0, 0),
+
+	Byte:                     Dimensionless,
+	VoltAmpere:               powerDim,
+	VoltAmpereSecond:         energyDim,
+	VoltAmpereReactive:       powerDim,
+	VoltAmpereReactiveSecond: energyDim,
+	JoulePerMeter:            energyLengthDim,
+	KilogramPerCubicMeter:    densityDim,
+	Degree:                   Dimensionless,
+
+	NephelometricTurbidityUnit: Dimensionless,
+}
+
+// ParseUnit checks that s is a unit of DefaultUnits or a product or quotient
+// of such units, such as "kg.m/s2", and returns it as a Unit.
+func ParseUnit(s string) (Unit, error) {
+	return DefaultUnits.Parse(s)
+}
+
+// Dimension returns the dimension of u in DefaultUnits.
+func (u Unit) Dimension() (Dimension, error) {
+	return DefaultUnits.Dimension(u)
+}
+
+// Compatible reports whether u and other are compatible in DefaultUnits.
+func (u Unit) Compatible(other Unit) bool {
+	return DefaultUnits.Compatible(u, other)
+}
+
+// Parse checks that s is a registered unit or a product or quotient of
+// registered units and returns it as a Unit.
+func (g *UnitRegistry) Parse(s string) (Unit, error) {
+	u := Unit(s)
+	if _, err := g.Dimension(u); err != nil {
+		return None, err
+	}
+	return u, nil
+}
+
+// Dimension returns the dimension of u. Units that are not registered are
+// parsed as expressions of registered units: terms joined by "." and at most
+// one "/", each term optionally followed by an integer exponent.
+func (g *UnitRegistry) Dimension(u Unit) (Dimension, error) {
+	if d, ok := g.dimension(u); ok {
+		return d, nil
+	}
+	s := string(u)
+	num, den := s, ""
+	i := strings.IndexByte(s, '/')
+	if i >= 0 {
+		num, den = s[:i], s[i+1:]
+	}
+	var d Dimension
+	if num != "1" {
+		if err := g.addTerms(&d, num, 1); err != nil {
+			return Dimension{}, fmt.Errorf("%w: %q", err, s)
+		}
+	}
+	if i >= 0 {
+		if err := g.addTerms(&d, den, -1); err != nil {
+			return Dimension{}, fmt.Errorf("%w: %q", err, s)
+		}
+	}
+	return d, nil
+}
+
+// Compatible reports whether values in u and other measure the same kind of
+// quantity. Dimensionless units are only compatible when they share their
+// primary unit, so that counts are not mistaken for ratios.
+func (g *UnitRegistry) Compatible(u, other Unit) bool {
+	du, err := g.Dimension(u)
+	if err != nil {
+		return false
+	}
+	do, err := g.Dimension(other)
+	if err != nil || du != do {
+		return false
+	}
+	if du == Dimensionless {
+		return g.secondary(u).primary == g.secondary(other).primary
+	}
+	return true
+}
+
+func (g *UnitRegistry) addTerms(d *Dimension, expr string, sign int) error {
+	for _, term := range strings.Split(expr, ".") {
+		if term == "" {
+			return ErrUnknownUnit
+		}
+		td, ok := g.dimension(Unit(term))
+		exp := 1
+		if !ok {
+			i := strings.LastIndexFunc(term, func(r rune) bool {
+				return (r < '0' || r > '9') && r != '-'
+			}) + 1
+			if i == 0 || i == len(term) {
+				return ErrUnknownUnit
+			}
+			var err error
+			if exp, err = strconv.Atoi(term[i:]); err != nil || exp < -9 || exp > 9 {
+				return ErrUnknownUnit
+			}
+			if td, ok = g.dimension(Unit(term[:i])); !ok {
+				return ErrUnknownUnit
+			}
+		}
+		for k := range td {
+			d[k] += td[k] * int8(exp*sign)
+		}
+	}
+	return nil
+}
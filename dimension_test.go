@@ -0,0 +1,61 @@
+package msgtypes
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseUnit(t *testing.T) {
+	for _, c := range []struct {
+		s    string
+		want Dimension
+	}{
+		{"W", powerDim},
+		{"kWh", energyDim},
+		{"kg.m/s2", forceDim},
+		{"kg.m2/s3", powerDim},
+		{"1/h", frequencyDim},
+		{"cm3", volumeDim},
+		{"J/kg.K", dim(2, 0, -2, 0, -1, 0, 0)},
+	} {
+		u, err := ParseUnit(c.s)
+		if err != nil {
+			t.Fatalf("ParseUnit(%q): %v", c.s, err)
+		}
+		if d, _ := u.Dimension(); d != c.want {
+			t.Errorf("%q has dimension %v, want %v", c.s, d, c.want)
+		}
+	}
+	for _, s := range []string{"furlong", "m/", "/s/m", "m.", "kg^2", "m99"} {
+		if _, err := ParseUnit(s); !errors.Is(err, ErrUnknownUnit) {
+			t.Errorf("ParseUnit(%q) = %v, want %v", s, err, ErrUnknownUnit)
+		}
+	}
+
+	if !Celsius.Compatible(Kelvin) || !Unit("kg.m/s2").Compatible(Newton) || !Percent.Compatible(PartsPerMillion) {
+		t.Fatal("expected compatible units")
+	}
+	if Count.Compatible(Ratio) || Watt.Compatible(WattHour) || Unit("furlong").Compatible(Meter) {
+		t.Fatal("expected incompatible units")
+	}
+
+	v := 1.0
+	p := Pack{Records: []Record{{Name: "a", Unit: "Celsius", Value: &v}}}
+	if err := Validate(p); err != nil {
+		t.Fatal(err)
+	}
+	var verr *ValidationError
+	err := ValidationOptions{KnownUnits: true}.Validate(p)
+	if !errors.As(err, &verr) || verr.Field != "u" || !errors.Is(err, ErrUnknownUnit) {
+		t.Fatalf("got %v, want unknown unit in u", err)
+	}
+
+	p = Pack{Records: []Record{{BaseUnit: "W.h", Name: "a", Unit: "kg.m/s2", Value: &v}}}
+	err = ValidationOptions{KnownUnits: true}.Validate(p)
+	if !errors.As(err, &verr) || verr.Field != "bu" || !errors.Is(err, ErrUnknownUnit) {
+		t.Fatalf("got %v, want unknown unit in bu", err)
+	}
+	if err := (ValidationOptions{KnownUnits: true, UnitExpressions: true}).Validate(p); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,55 @@
+package msgtypes
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ValidationError describes why a record of a pack failed validation.
+// Err is one of the sentinel errors such as ErrBadChar or ErrNoValues.
+type ValidationError struct {
+	Index int
+	Name  string
+	Field string
+	Err   error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("record %d (%q): %s: %v", e.Index, e.Name, e.Field, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationErrors is the list of errors returned by ValidateAll.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	s := make([]string, len(e))
+	for i, err := range e {
+		s[i] = err.Error()
+	}
+	return strings.Join(s, "; ")
+}
+
+// Is reports whether any of the errors matches target.
+func (e ValidationErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first error that matches target.
+func (e ValidationErrors) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,552 @@
+package msgtypes
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// The EXI representation of RFC 8428 section 8 is produced with the
+// schema-informed grammars of the SenML XML schema, in strict mode and with
+// bit-packed alignment. The header carries the EXI options that say so,
+// schemaId "a" and strict, as the RFC requires.
+
+type exiType int
+
+const (
+	exiString exiType = iota
+	exiDouble
+	exiInt
+	exiBoolean
+)
+
+// exiAttributes lists the attributes of the senml element in the lexical
+// order that determines their event codes.
+var exiAttributes = []struct {
+	label string
+	typ   exiType
+}{
+	{"bn", exiString},
+	{"bs", exiDouble},
+	{"bt", exiDouble},
+	{"bu", exiString},
+	{"bv", exiDouble},
+	{"bver", exiInt},
+	{"l", exiString},
+	{"n", exiString},
+	{"s", exiDouble},
+	{"t", exiDouble},
+	{"u", exiString},
+	{"ut", exiDouble},
+	{"v", exiDouble},
+	{"vb", exiBoolean},
+	{"vd", exiString},
+	{"vs", exiString},
+}
+
+const (
+	exiHeader          = 0xa0 // distinguishing bits, options present, final version 1
+	exiHeaderNoOptions = 0x80 // as exiHeader, without options
+	exiCookie          = "$EXI"
+
+	// exiOptions is the EXI options document <header><common><schemaId>a
+	// </schemaId></common><strict/></header> in exiOptionsBits bits: SE(header)
+	// 0, SE(common) 01, SE(schemaId) 10, CH 0, the string "a" as 3 0x61, and
+	// SE(strict) 0. The end events take no bits.
+	exiOptions     = 0x1806c2
+	exiOptionsBits = 23
+
+	// Event codes of the document grammar: the global elements sorted, then
+	// SE(*).
+	exiSenmlElement  = 0
+	exiSensmlElement = 1
+	exiDocumentCodes = 3
+)
+
+// exiStrings is the value partition of the EXI string table.
+type exiStrings struct {
+	global []string
+	local  map[string][]string
+}
+
+func (t *exiStrings) add(label, s string) {
+	if s == "" {
+		return
+	}
+	if t.local == nil {
+		t.local = make(map[string][]string)
+	}
+	t.global = append(t.global, s)
+	t.local[label] = append(t.local[label], s)
+}
+
+// exiWidth returns the number of bits of an n-bit unsigned integer that
+// distinguishes n values.
+func exiWidth(n int) int {
+	if n <= 1 {
+		return 0
+	}
+	return bits.Len(uint(n - 1))
+}
+
+type exiWriter struct {
+	buf  []byte
+	nbit int
+	str  exiStrings
+}
+
+func (w *exiWriter) writeBits(v uint64, n int) {
+	for i := n - 1; i >= 0; i-- {
+		if w.nbit%8 == 0 {
+			w.buf = append(w.buf, 0)
+		}
+		if v>>uint(i)&1 == 1 {
+			w.buf[len(w.buf)-1] |= 0x80 >> uint(w.nbit%8)
+		}
+		w.nbit++
+	}
+}
+
+func (w *exiWriter) writeUint(v uint64) {
+	for {
+		b := v & 0x7f
+		v >>= 7
+		if v != 0 {
+			b |= 0x80
+		}
+		w.writeBits(b, 8)
+		if v == 0 {
+			return
+		}
+	}
+}
+
+func (w *exiWriter) writeInt(v int64) {
+	if v < 0 {
+		w.writeBits(1, 1)
+		w.writeUint(uint64(-(v + 1)))
+		return
+	}
+	w.writeBits(0, 1)
+	w.writeUint(uint64(v))
+}
+
+func (w *exiWriter) writeDouble(f float64) error {
+	switch {
+	case math.IsNaN(f):
+		w.writeInt(0)
+		w.writeInt(-1 << 14)
+		return nil
+	case math.IsInf(f, 1):
+		w.writeInt(1)
+		w.writeInt(-1 << 14)
+		return nil
+	case math.IsInf(f, -1):
+		w.writeInt(-1)
+		w.writeInt(-1 << 14)
+		return nil
+	}
+	// The shortest decimal form of f, as mantissa * 10**exponent.
+	s := strconv.FormatFloat(f, 'e', -1, 64)
+	i := strings.IndexByte(s, 'e')
+	exp, err := strconv.Atoi(s[i+1:])
+	if err != nil {
+		return err
+	}
+	digits := strings.Replace(s[:i], ".", "", 1)
+	if j := strings.IndexByte(s[:i], '.'); j >= 0 {
+		exp -= i - j - 1
+	}
+	m, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return err
+	}
+	w.writeInt(m)
+	w.writeInt(int64(exp))
+	return nil
+}
+
+func (w *exiWriter) writeString(label, s string) {
+	local := w.str.local[label]
+	for i, l := range local {
+		if l == s {
+			w.writeUint(0)
+			w.writeBits(uint64(i), exiWidth(len(local)))
+			return
+		}
+	}
+	for i, g := range w.str.global {
+		if g == s {
+			w.writeUint(1)
+			w.writeBits(uint64(i), exiWidth(len(w.str.global)))
+			return
+		}
+	}
+	runes := []rune(s)
+	w.writeUint(uint64(len(runes)) + 2)
+	for _, r := range runes {
+		w.writeUint(uint64(r))
+	}
+	w.str.add(label, s)
+}
+
+func encodeEXI(records []Record) ([]byte, error) {
+	if len(records) == 0 {
+		// sensml requires at least one senml element.
+		return nil, fmt.Errorf("%w: EXI pack without records", ErrUnsupportedFormat)
+	}
+	w := &exiWriter{}
+	w.writeBits(exiHeader, 8)
+	w.writeBits(exiOptions, exiOptionsBits)
+	w.writeBits(exiSensmlElement, exiWidth(exiDocumentCodes))
+	for i, r := range records {
+		if i > 0 {
+			w.writeBits(0, 1) // SE(senml) rather than EE
+		}
+		attrs, err := exiRecordAttributes(&r)
+		if err != nil {
+			return nil, err
+		}
+		state := 0
+		for k, a := range exiAttributes {
+			v, ok := attrs[a.label]
+			if !ok {
+				continue
+			}
+			w.writeBits(uint64(k-state), exiWidth(len(exiAttributes)-state+1))
+			state = k + 1
+			switch a.typ {
+			case exiString:
+				w.writeString(a.label, v.(string))
+			case exiDouble:
+				if err := w.writeDouble(v.(float64)); err != nil {
+					return nil, err
+				}
+			case exiInt:
+				w.writeInt(v.(int64))
+			case exiBoolean:
+				if v.(bool) {
+					w.writeBits(1, 1)
+				} else {
+					w.writeBits(0, 1)
+				}
+			}
+		}
+		// EE of senml, the last event of its current state.
+		w.writeBits(uint64(len(exiAttributes)-state), exiWidth(len(exiAttributes)-state+1))
+	}
+	w.writeBits(1, 1) // EE of sensml
+	return w.buf, nil
+}
+
+// exiRecordAttributes returns the attributes of r keyed by label, following
+// the omitempty rules of the XML representation.
+func exiRecordAttributes(r *Record) (map[string]interface{}, error) {
+	if r.VectorValue != nil {
+		return nil, fmt.Errorf("%w: vv in EXI", ErrUnsupportedFormat)
+	}
+	if r.EnumValue != nil {
+		return nil, fmt.Errorf("%w: ve in EXI", ErrUnsupportedFormat)
+	}
+	if labels := r.extensionLabels(); len(labels) > 0 {
+		return nil, fmt.Errorf("%w: %s in EXI", ErrUnsupportedFormat, labels[0])
+	}
+	attrs := make(map[string]interface{})
+	str := func(label, s string) {
+		if s != "" {
+			attrs[label] = s
+		}
+	}
+	num := func(label string, f float64) {
+		if f != 0 {
+			attrs[label] = f
+		}
+	}
+	str("bn", r.BaseName)
+	num("bs", r.BaseSum)
+	num("bt", r.BaseTime)
+	str("bu", r.BaseUnit)
+	num("bv", r.BaseValue)
+	if r.BaseVersion != 0 {
+		attrs["bver"] = int64(r.BaseVersion)
+	}
+	str("l", r.Link)
+	str("n", r.Name)
+	if r.Sum != nil {
+		attrs["s"] = *r.Sum
+	}
+	num("t", r.Time)
+	str("u", r.Unit)
+	num("ut", r.UpdateTime)
+	if v := r.floatValue(); v != nil {
+		attrs["v"] = *v
+	}
+	if r.BoolValue != nil {
+		attrs["vb"] = *r.BoolValue
+	}
+	if r.DataValue != nil {
+		attrs["vd"] = encodeData(r.DataValue)
+	}
+	if r.StringValue != nil {
+		attrs["vs"] = *r.StringValue
+	}
+	return attrs, nil
+}
+
+type exiReader struct {
+	buf  []byte
+	nbit int
+	str  exiStrings
+}
+
+func (r *exiReader) readBits(n int) (uint64, error) {
+	if r.nbit+n > len(r.buf)*8 {
+		return 0, ErrMalformedPack
+	}
+	var v uint64
+	for i := 0; i < n; i++ {
+		b := r.buf[r.nbit/8] >> uint(7-r.nbit%8) & 1
+		v = v<<1 | uint64(b)
+		r.nbit++
+	}
+	return v, nil
+}
+
+func (r *exiReader) readUint() (uint64, error) {
+	var v uint64
+	for shift := uint(0); shift < 64; shift += 7 {
+		b, err := r.readBits(8)
+		if err != nil {
+			return 0, err
+		}
+		v |= (b & 0x7f) << shift
+		if b&0x80 == 0 {
+			return v, nil
+		}
+	}
+	return 0, ErrMalformedPack
+}
+
+func (r *exiReader) readInt() (int64, error) {
+	sign, err := r.readBits(1)
+	if err != nil {
+		return 0, err
+	}
+	m, err := r.readUint()
+	if err != nil {
+		return 0, err
+	}
+	if m > math.MaxInt64 {
+		return 0, ErrMalformedPack
+	}
+	if sign == 1 {
+		return -int64(m) - 1, nil
+	}
+	return int64(m), nil
+}
+
+func (r *exiReader) readDouble() (float64, error) {
+	m, err := r.readInt()
+	if err != nil {
+		return 0, err
+	}
+	e, err := r.readInt()
+	if err != nil {
+		return 0, err
+	}
+	if e == -1<<14 {
+		switch m {
+		case 1:
+			return math.Inf(1), nil
+		case -1:
+			return math.Inf(-1), nil
+		}
+		return math.NaN(), nil
+	}
+	if e < -(1<<14-1) || e > 1<<14-1 {
+		return 0, ErrMalformedPack
+	}
+	f, err := strconv.ParseFloat(strconv.FormatInt(m, 10)+"e"+strconv.FormatInt(e, 10), 64)
+	if err != nil && !errors.Is(err, strconv.ErrRange) {
+		return 0, ErrMalformedPack
+	}
+	return f, nil
+}
+
+func (r *exiReader) readString(label string) (string, error) {
+	n, err := r.readUint()
+	if err != nil {
+		return "", err
+	}
+	table := r.str.global
+	switch n {
+	case 0:
+		table = r.str.local[label]
+		fallthrough
+	case 1:
+		i, err := r.readBits(exiWidth(len(table)))
+		if err != nil {
+			return "", err
+		}
+		if i >= uint64(len(table)) {
+			return "", ErrMalformedPack
+		}
+		return table[i], nil
+	}
+	n -= 2
+	if n > uint64(len(r.buf)) {
+		return "", ErrMalformedPack
+	}
+	var sb strings.Builder
+	for ; n > 0; n-- {
+		c, err := r.readUint()
+		if err != nil {
+			return "", err
+		}
+		if c > math.MaxInt32 {
+			return "", ErrMalformedPack
+		}
+		sb.WriteRune(rune(c))
+	}
+	s := sb.String()
+	r.str.add(label, s)
+	return s, nil
+}
+
+func decodeEXI(msg []byte) (Records, error) {
+	msg = []byte(strings.TrimPrefix(string(msg), exiCookie))
+	r := &exiReader{buf: msg}
+	h, err := r.readBits(8)
+	if err != nil {
+		return nil, err
+	}
+	switch h {
+	case exiHeader:
+		o, err := r.readBits(exiOptionsBits)
+		if err != nil {
+			return nil, err
+		}
+		if o != exiOptions {
+			return nil, fmt.Errorf("%w: EXI options other than schemaId \"a\" and strict", ErrUnsupportedFormat)
+		}
+	case exiHeaderNoOptions:
+	default:
+		return nil, fmt.Errorf("%w: EXI header %#x", ErrUnsupportedFormat, h)
+	}
+	root, err := r.readBits(exiWidth(exiDocumentCodes))
+	if err != nil {
+		return nil, err
+	}
+	if root != exiSenmlElement && root != exiSensmlElement {
+		return nil, fmt.Errorf("%w: EXI root element", ErrMalformedPack)
+	}
+	var records Records
+	if root == exiSenmlElement {
+		rec, err := r.readRecord()
+		if err != nil {
+			return nil, err
+		}
+		return Records{rec}, nil
+	}
+	for {
+		if len(records) > 0 {
+			ee, err := r.readBits(1)
+			if err != nil {
+				return nil, err
+			}
+			if ee == 1 {
+				return records, nil
+			}
+		}
+		rec, err := r.readRecord()
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+}
+
+func (r *exiReader) readRecord() (Record, error) {
+	var rec Record
+	state := 0
+	for {
+		code, err := r.readBits(exiWidth(len(exiAttributes) - state + 1))
+		if err != nil {
+			return Record{}, err
+		}
+		k := state + int(code)
+		if k == len(exiAttributes) {
+			return rec, nil
+		}
+		if k > len(exiAttributes) {
+			return Record{}, ErrMalformedPack
+		}
+		state = k + 1
+		a := exiAttributes[k]
+		switch a.typ {
+		case exiString:
+			s, err := r.readString(a.label)
+			if err != nil {
+				return Record{}, err
+			}
+			switch a.label {
+			case "bn":
+				rec.BaseName = s
+			case "bu":
+				rec.BaseUnit = s
+			case "l":
+				rec.Link = s
+			case "n":
+				rec.Name = s
+			case "u":
+				rec.Unit = s
+			case "vd":
+				if rec.DataValue, err = decodeData(s); err != nil {
+					return Record{}, err
+				}
+			case "vs":
+				rec.StringValue = &s
+			}
+		case exiDouble:
+			f, err := r.readDouble()
+			if err != nil {
+				return Record{}, err
+			}
+			switch a.label {
+			case "bs":
+				rec.BaseSum = f
+			case "bt":
+				rec.BaseTime = f
+			case "bv":
+				rec.BaseValue = f
+			case "s":
+				rec.Sum = &f
+			case "t":
+				rec.Time = f
+			case "ut":
+				rec.UpdateTime = f
+			case "v":
+				rec.Value = &f
+			}
+		case exiInt:
+			n, err := r.readInt()
+			if err != nil {
+				return Record{}, err
+			}
+			if n < 0 || n > math.MaxInt32 {
+				return Record{}, ErrMalformedPack
+			}
+			rec.BaseVersion = uint(n)
+		case exiBoolean:
+			b, err := r.readBits(1)
+			if err != nil {
+				return Record{}, err
+			}
+			vb := b == 1
+			rec.BoolValue = &vb
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package msgtypes
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestEXI(t *testing.T) {
+	v := 1.0
+	b, err := Encode(Pack{Records: []Record{{Name: "a", Value: &v}}}, EXI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := hex.EncodeToString(b), "a0300d849c0d8500200e"; got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+
+	v1, v2, s := 23.1, -0.5e-9, 1234.5
+	vs, vb, vd := "text", true, []byte("data")
+	p := Pack{Records: []Record{
+		{BaseName: "urn:dev:ow:10e2073a01080063:", BaseTime: 1.320067464e+09, BaseUnit: "Cel", BaseVersion: 10, Name: "temp", Value: &v1},
+		{Name: "temp", Time: 60, Value: &v2, UpdateTime: 30},
+		{Name: "energy", Unit: "J", Sum: &s},
+		{Name: "label", StringValue: &vs, Link: "[]"},
+		{Name: "on", BoolValue: &vb},
+		{Name: "blob", DataValue: vd},
+	}}
+	b, err = Encode(p, EXI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Decode(append([]byte("$EXI"), b...), SenSMLEXI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Records, p.Records) {
+		t.Fatalf("got %+v, want %+v", got.Records, p.Records)
+	}
+
+	for i := range b {
+		if _, err := Decode(b[:i], EXI); err == nil {
+			t.Fatalf("truncated pack of %d bytes decoded", i)
+		}
+	}
+	vv := []float64{1}
+	if _, err := Encode(Pack{Records: []Record{{Name: "a", VectorValue: &vv}}}, EXI); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Fatalf("got %v, want %v", err, ErrUnsupportedFormat)
+	}
+}
+
+func TestEXIRFCExample(t *testing.T) {
+	// The XML example of RFC 8428 section 8 in EXI: the options with
+	// schemaId "a" and strict, then the pack.
+	const xml = `<sensml xmlns="urn:ietf:params:xml:ns:senml">
+  <senml bn="urn:dev:ow:10e2073a0108006:" bt="1.276020076001e+09"
+  bu="A" bver="5" n="voltage" u="V" v="120.1"></senml>
+  <senml n="current" t="-5" v="1.2"></senml>
+</sensml>`
+	exi, err := hex.DecodeString("a0300d848075d5c9b8e99195d8e9bddce8c4c194c8c0dccd84c0c4c0e0c0c0d8e85c3b7c98b224b0200341102884bb37b63a30b3b2901ab15884c031c258dd5c9c995b9d06080040c807")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// bver 5 predates RFC 8428, so the packs are compared unvalidated.
+	want, err := decodePack([]byte(xml), SenSMLXML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := decodePack(exi, SenSMLEXI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Records, want.Records) {
+		t.Fatalf("got %+v, want %+v", got.Records, want.Records)
+	}
+	b, err := encodeEXI(want.Records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, exi) {
+		t.Fatalf("got %x, want %x", b, exi)
+	}
+
+	// Other options, such as schemaId "b", are not understood.
+	exi[3] ^= 0x0c
+	if _, err := decodePack(exi, SenSMLEXI); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Fatalf("got %v, want %v", err, ErrUnsupportedFormat)
+	}
+}
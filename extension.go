@@ -0,0 +1,134 @@
+package msgtypes
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+var (
+	ErrExtensionLabel = errors.New("invalid extension label")
+	ErrExtensionType  = errors.New("extension value has the wrong type")
+)
+
+// ExtensionType is the value type of a registered extension field.
+type ExtensionType int
+
+const (
+	NumberExtension ExtensionType = 1 + iota
+	StringExtension
+	BoolExtension
+)
+
+// Extension declares a SenML extension field. Label is used in JSON, XML and
+// PROTO; CBORLabel, when not zero, is the integer label used in CBOR instead.
+type Extension struct {
+	Label     string
+	CBORLabel int
+	Type      ExtensionType
+}
+
+var (
+	extensionsMu   sync.RWMutex
+	extensions     = map[string]Extension{}
+	cborExtensions = map[int64]Extension{}
+)
+
+// RegisterExtension declares a typed extension field. Values of registered
+// extensions are converted to float64, string or bool when decoded and their
+// must-understand labels are accepted by Validate.
+func RegisterExtension(ext Extension) error {
+	if ext.Label == "" || knownLabels[ext.Label] || (ext.CBORLabel != 0 && knownCBORLabels[int64(ext.CBORLabel)]) {
+		return fmt.Errorf("%w: %q", ErrExtensionLabel, ext.Label)
+	}
+	if _, err := strconv.ParseInt(ext.Label, 10, 64); err == nil {
+		return fmt.Errorf("%w: %q", ErrExtensionLabel, ext.Label)
+	}
+	extensionsMu.Lock()
+	defer extensionsMu.Unlock()
+	if _, ok := extensions[ext.Label]; ok {
+		return fmt.Errorf("%w: %q already registered", ErrExtensionLabel, ext.Label)
+	}
+	if _, ok := cborExtensions[int64(ext.CBORLabel)]; ok {
+		return fmt.Errorf("%w: CBOR label %d already registered", ErrExtensionLabel, ext.CBORLabel)
+	}
+	extensions[ext.Label] = ext
+	if ext.CBORLabel != 0 {
+		cborExtensions[int64(ext.CBORLabel)] = ext
+	}
+	return nil
+}
+
+// LookupExtension returns the registered extension with the given label.
+func LookupExtension(label string) (Extension, bool) {
+	extensionsMu.RLock()
+	defer extensionsMu.RUnlock()
+	ext, ok := extensions[label]
+	return ext, ok
+}
+
+func lookupCBORExtension(label int64) (Extension, bool) {
+	extensionsMu.RLock()
+	defer extensionsMu.RUnlock()
+	ext, ok := cborExtensions[label]
+	return ext, ok
+}
+
+// extensionValue converts a decoded value of the extension field label to
+// the registered type, if any. XML attribute values arrive as strings.
+func extensionValue(label string, v interface{}) (interface{}, error) {
+	ext, ok := LookupExtension(label)
+	if !ok {
+		return v, nil
+	}
+	switch ext.Type {
+	case NumberExtension:
+		switch n := v.(type) {
+		case string:
+			f, err := strconv.ParseFloat(n, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s", ErrExtensionType, label)
+			}
+			return f, nil
+		case int64, uint64, float64:
+			return numericToFloat64(n), nil
+		}
+	case StringExtension:
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+	case BoolExtension:
+		switch b := v.(type) {
+		case string:
+			v, err := strconv.ParseBool(b)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s", ErrExtensionType, label)
+			}
+			return v, nil
+		case bool:
+			return b, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrExtensionType, label)
+}
+
+// cborLabel returns the CBOR map key of the extension field label.
+func cborLabel(label string) interface{} {
+	if ext, ok := LookupExtension(label); ok && ext.CBORLabel != 0 {
+		return int64(ext.CBORLabel)
+	}
+	if n, err := strconv.ParseInt(label, 10, 64); err == nil {
+		return n
+	}
+	return label
+}
+
+// extensionLabel returns the label of the extension field with the integer
+// CBOR label n.
+func extensionLabel(n int64) string {
+	if ext, ok := lookupCBORExtension(n); ok {
+		return ext.Label
+	}
+	return strconv.FormatInt(n, 10)
+}
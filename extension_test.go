@@ -0,0 +1,55 @@
+package msgtypes
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/fxamacker/cbor"
+)
+
+func TestExtensions(t *testing.T) {
+	if err := RegisterExtension(Extension{Label: "fw_", CBORLabel: 100, Type: StringExtension}); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterExtension(Extension{Label: "lqi", Type: NumberExtension}); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterExtension(Extension{Label: "n"}); !errors.Is(err, ErrExtensionLabel) {
+		t.Fatalf("got %v, want %v", err, ErrExtensionLabel)
+	}
+
+	v := 20.6
+	p := Pack{Records: []Record{{
+		Name:  "temp",
+		Value: &v,
+		Extensions: map[string]interface{}{
+			"fw_":    "1.2.3",
+			"lqi":    87.0,
+			"rssi_x": "-71",
+		},
+	}}}
+
+	for _, f := range []Format{JSON, XML, CBOR, PROTO} {
+		data, err := Encode(p, f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p2, err := Decode(data, f)
+		if err != nil {
+			t.Fatalf("format %d: %v", f, err)
+		}
+		ext := p2.Records[0].Extensions
+		if ext["fw_"] != "1.2.3" || ext["lqi"] != 87.0 || ext["rssi_x"] != "-71" {
+			t.Fatalf("format %d: unexpected extensions %#v", f, ext)
+		}
+	}
+
+	data, _ := Encode(p, CBOR)
+	var fields []map[interface{}]interface{}
+	if err := cbor.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	if fields[0][uint64(100)] != "1.2.3" {
+		t.Fatalf("registered CBOR label not used: %#v", fields[0])
+	}
+}
@@ -0,0 +1,233 @@
+package msgtypes
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"github.com/fxamacker/cbor"
+)
+
+var ErrFetchField = errors.New("field not allowed in FETCH record")
+
+// NewFetch returns a FETCH pack (RFC 8790) selecting the given names.
+func NewFetch(names ...string) Pack {
+	p := Pack{Records: make([]Record, len(names))}
+	for i, n := range names {
+		p.Records[i].Name = n
+	}
+	return p
+}
+
+// ValidateFetch checks that p is a valid FETCH pack: its records select by
+// name and optionally time, so they carry no values, sums or units.
+func ValidateFetch(p Pack) error {
+	var bname string
+	for i, r := range p.Records {
+		if r.BaseName != "" {
+			bname = r.BaseName
+		}
+		name := bname + r.Name
+		if field := fetchField(&r); field != "" {
+			return &ValidationError{Index: i, Name: name, Field: field, Err: ErrFetchField}
+		}
+		if len(name) == 0 {
+			return &ValidationError{Index: i, Name: name, Field: "n", Err: ErrEmptyName}
+		}
+		if err := validateName(name); err != nil {
+			return &ValidationError{Index: i, Name: name, Field: "n", Err: err}
+		}
+	}
+	return nil
+}
+
+// fetchField returns the label of the first field of r not allowed in a
+// FETCH record.
+func fetchField(r *Record) string {
+	switch {
+	case r.BaseUnit != "":
+		return "bu"
+	case r.BaseValue != 0:
+		return "bv"
+	case r.BaseSum != 0:
+		return "bs"
+	case r.Unit != "":
+		return "u"
+	case r.UpdateTime != 0:
+		return "ut"
+	case r.Value != nil || r.DecimalValue != nil:
+		return "v"
+	case r.StringValue != nil:
+		return "vs"
+	case r.BoolValue != nil:
+		return "vb"
+	case r.DataValue != nil:
+		return "vd"
+	case r.VectorValue != nil:
+		return "vv"
+	case r.EnumValue != nil:
+		return "ve"
+	case r.Sum != nil:
+		return "s"
+	case r.Link != "":
+		return "l"
+	}
+	if labels := r.extensionLabels(); len(labels) > 0 {
+		return labels[0]
+	}
+	return ""
+}
+
+// ValidatePatch checks that p is a valid iPATCH pack. Records without a value
+// or sum are allowed; they remove the records they match.
+func ValidatePatch(p Pack) error {
+	return ValidationOptions{AllowNoValue: true}.Validate(p)
+}
+
+// DecodeFetch decodes and validates a FETCH pack. Over CoAP and HTTP these
+// are exchanged as EtchJSON or EtchCBOR, which are read like JSON and CBOR.
+func DecodeFetch(msg []byte, format Format) (Pack, error) {
+	p, err := decodePack(msg, format)
+	if err != nil {
+		return Pack{}, err
+	}
+	return p, ValidateFetch(p)
+}
+
+// EncodeFetch validates and encodes a FETCH pack.
+func EncodeFetch(p Pack, format Format) ([]byte, error) {
+	if err := ValidateFetch(p); err != nil {
+		return nil, err
+	}
+	return Encode(p, format)
+}
+
+// DecodePatch decodes and validates an iPATCH pack. A null value, as used in
+// JSON and CBOR to remove a record, decodes as a record without a value.
+func DecodePatch(msg []byte, format Format) (Pack, error) {
+	p, err := decodePack(msg, format)
+	if err != nil {
+		return Pack{}, err
+	}
+	return p, ValidatePatch(p)
+}
+
+// EncodePatch validates and encodes an iPATCH pack. In JSON and CBOR,
+// records removing a target record are given a null value.
+func EncodePatch(p Pack, format Format) ([]byte, error) {
+	if err := ValidatePatch(p); err != nil {
+		return nil, err
+	}
+	base := format.base()
+	if base != JSON && base != CBOR {
+		return Encode(p, format)
+	}
+	records := make([]interface{}, len(p.Records))
+	for i, r := range p.Records {
+		if removes(&r) {
+			records[i] = removal(r)
+		} else {
+			records[i] = r
+		}
+	}
+	if base == JSON {
+		return json.Marshal(records)
+	}
+	return cbor.Marshal(records, cbor.CanonicalEncOptions())
+}
+
+// removes reports whether the patch record r removes the records it matches.
+func removes(r *Record) bool {
+	return r.Value == nil && r.DecimalValue == nil && r.StringValue == nil && r.BoolValue == nil &&
+		r.DataValue == nil && r.VectorValue == nil && r.EnumValue == nil && r.Sum == nil
+}
+
+// removal is a patch record encoded with a null value.
+type removal Record
+
+func (r removal) MarshalJSON() ([]byte, error) {
+	b, err := json.Marshal(Record(r))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > 2 {
+		b = append(b[:len(b)-1], ',')
+	} else {
+		b = b[:1]
+	}
+	return append(b, `"v":null}`...), nil
+}
+
+func (r removal) MarshalCBOR() ([]byte, error) {
+	b, err := cbor.Marshal(Record(r), cbor.CanonicalEncOptions())
+	if err != nil {
+		return nil, err
+	}
+	var fields map[interface{}]interface{}
+	if err := cbor.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	fields[uint64(2)] = nil
+	return cbor.Marshal(fields, cbor.CanonicalEncOptions())
+}
+
+// Select returns the resolved records of target matching a record of the
+// FETCH pack by resolved name and, when the FETCH record has one, by time.
+func Select(target Pack, fetch Pack) Pack {
+	t, f := resolve(target), resolve(fetch)
+	var records []Record
+	for _, r := range t.Records {
+		for i := range f.Records {
+			if matches(&f.Records[i], &r) {
+				records = append(records, r)
+				break
+			}
+		}
+	}
+	t.Records = records
+	return t
+}
+
+// ApplyPatch applies an iPATCH pack to target and returns the resolved
+// result. Every patch record replaces the target records matching its
+// resolved name and, when it has one, its time; a patch record without a
+// value removes them instead. Patch records that match nothing are added.
+func ApplyPatch(target Pack, patch Pack) (Pack, error) {
+	if err := ValidatePatch(patch); err != nil {
+		return Pack{}, err
+	}
+	t, err := Normalize(target)
+	if err != nil {
+		return Pack{}, err
+	}
+	for _, pr := range resolve(patch).Records {
+		records := t.Records[:0:0]
+		found := false
+		for _, r := range t.Records {
+			if !matches(&pr, &r) {
+				records = append(records, r)
+				continue
+			}
+			found = true
+			if !removes(&pr) {
+				n := pr
+				if n.Time == 0 {
+					n.Time = r.Time
+				}
+				records = append(records, n)
+			}
+		}
+		if !found && !removes(&pr) {
+			records = append(records, pr)
+		}
+		t.Records = records
+	}
+	sort.Stable(&t)
+	return t, nil
+}
+
+// matches reports whether the resolved FETCH or iPATCH record sel selects
+// the resolved record r.
+func matches(sel, r *Record) bool {
+	return sel.Name == r.Name && (sel.Time == 0 || sel.Time == r.Time)
+}
@@ -0,0 +1,83 @@
+package msgtypes
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFetchPatch(t *testing.T) {
+	v1, v2, v3 := 1.0, 2.0, 3.0
+	target := Pack{Records: []Record{
+		{BaseName: "dev/", Name: "temp", Unit: "Cel", Value: &v1},
+		{Name: "hum", Unit: "%RH", Value: &v2},
+		{Name: "volt", Unit: "V", Value: &v3},
+	}}
+
+	for _, format := range []Format{JSON, XML, CBOR, PROTO} {
+		fetch := NewFetch("temp", "volt")
+		fetch.Records[0].BaseName = "dev/"
+		b, err := EncodeFetch(fetch, format)
+		if err != nil {
+			t.Fatal(format, err)
+		}
+		fetch, err = DecodeFetch(b, format)
+		if err != nil {
+			t.Fatal(format, err)
+		}
+		got := Select(target, fetch)
+		if len(got.Records) != 2 || got.Records[0].Name != "dev/temp" || got.Records[1].Name != "dev/volt" {
+			t.Fatalf("%d: unexpected selection %+v", format, got.Records)
+		}
+	}
+	bad := Pack{Records: []Record{{Name: "dev/temp", Value: &v1}}}
+	if _, err := EncodeFetch(bad, JSON); !errors.Is(err, ErrFetchField) {
+		t.Fatalf("got %v, want %v", err, ErrFetchField)
+	}
+	for _, msg := range []string{`[{}]`, `[{"t":5}]`} {
+		var verr *ValidationError
+		if _, err := DecodeFetch([]byte(msg), JSON); !errors.Is(err, ErrEmptyName) || !errors.As(err, &verr) {
+			t.Fatalf("%s: got %v, want %v", msg, err, ErrEmptyName)
+		}
+	}
+
+	v4, v5 := 4.0, 5.0
+	patch := Pack{Records: []Record{
+		{BaseName: "dev/", Name: "temp", Unit: "Cel", Value: &v4},
+		{Name: "hum"},
+		{Name: "power", Unit: "W", Value: &v5},
+	}}
+	for _, format := range []Format{JSON, XML, CBOR, PROTO} {
+		b, err := EncodePatch(patch, format)
+		if err != nil {
+			t.Fatal(format, err)
+		}
+		p, err := DecodePatch(b, format)
+		if err != nil {
+			t.Fatal(format, err)
+		}
+		got, err := ApplyPatch(target, p)
+		if err != nil {
+			t.Fatal(format, err)
+		}
+		want := []struct {
+			name  string
+			value float64
+		}{{"dev/temp", 4}, {"dev/volt", 3}, {"dev/power", 5}}
+		if len(got.Records) != len(want) {
+			t.Fatalf("%d: unexpected result %+v", format, got.Records)
+		}
+		for i, w := range want {
+			if r := got.Records[i]; r.Name != w.name || *r.Value != w.value {
+				t.Fatalf("%d: record %d is %+v, want %v", format, i, r, w)
+			}
+		}
+	}
+	for _, format := range []Format{JSON, SenSMLJSON, EtchJSON} {
+		if b, _ := EncodePatch(Pack{Records: []Record{{Name: "dev/hum"}}}, format); string(b) != `[{"n":"dev/hum","v":null}]` {
+			t.Fatalf("%d: unexpected removal %s", format, b)
+		}
+	}
+	if b, _ := EncodePatch(Pack{Records: []Record{{Name: "a"}}}, SenSMLCBOR); string(b) != "\x81\xa2\x00\x61a\x02\xf6" {
+		t.Fatalf("unexpected CBOR removal %x", b)
+	}
+}
@@ -0,0 +1,94 @@
+package msgtypes
+
+import (
+	"mime"
+	"strings"
+)
+
+var formatTypes = []struct {
+	format        Format
+	mediaType     string
+	contentFormat uint16
+}{
+	{JSON, "application/senml+json", 110},
+	{SenSMLJSON, "application/sensml+json", 111},
+	{CBOR, "application/senml+cbor", 112},
+	{SenSMLCBOR, "application/sensml+cbor", 113},
+	{XML, "application/senml+xml", 310},
+	{SenSMLXML, "application/sensml+xml", 311},
+	{EXI, "application/senml-exi", 114},
+	{SenSMLEXI, "application/sensml-exi", 115},
+	{EtchJSON, "application/senml-etch+json", 320},
+	{EtchCBOR, "application/senml-etch+cbor", 322},
+}
+
+// base returns the SenML format sharing the encoding of f.
+func (f Format) base() Format {
+	switch f {
+	case SenSMLJSON, EtchJSON:
+		return JSON
+	case SenSMLXML:
+		return XML
+	case SenSMLCBOR, EtchCBOR:
+		return CBOR
+	case SenSMLEXI:
+		return EXI
+	}
+	return f
+}
+
+// Streaming reports whether f is a SenSML format.
+func (f Format) Streaming() bool {
+	switch f {
+	case SenSMLJSON, SenSMLXML, SenSMLCBOR, SenSMLEXI:
+		return true
+	}
+	return false
+}
+
+// MediaType returns the IANA media type of f, or "" for formats without a
+// registered one such as PROTO.
+func (f Format) MediaType() string {
+	for _, t := range formatTypes {
+		if t.format == f {
+			return t.mediaType
+		}
+	}
+	return ""
+}
+
+// ContentFormat returns the CoAP Content-Format number of f. The result is
+// false for formats without a registered number such as PROTO.
+func (f Format) ContentFormat() (uint16, bool) {
+	for _, t := range formatTypes {
+		if t.format == f {
+			return t.contentFormat, true
+		}
+	}
+	return 0, false
+}
+
+// FormatFromMediaType returns the format of a media type. Parameters and
+// letter case are ignored, so a Content-Type header can be passed as is.
+func FormatFromMediaType(mediaType string) (Format, error) {
+	if mt, _, err := mime.ParseMediaType(mediaType); err == nil {
+		mediaType = mt
+	}
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	for _, t := range formatTypes {
+		if t.mediaType == mediaType {
+			return t.format, nil
+		}
+	}
+	return 0, ErrUnsupportedFormat
+}
+
+// FormatFromContentFormat returns the format of a CoAP Content-Format number.
+func FormatFromContentFormat(cf uint16) (Format, error) {
+	for _, t := range formatTypes {
+		if t.contentFormat == cf {
+			return t.format, nil
+		}
+	}
+	return 0, ErrUnsupportedFormat
+}
@@ -0,0 +1,46 @@
+package msgtypes
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFormatMediaTypes(t *testing.T) {
+	for _, f := range []Format{JSON, XML, CBOR, EXI, SenSMLJSON, SenSMLXML, SenSMLCBOR, SenSMLEXI, EtchJSON, EtchCBOR} {
+		cf, ok := f.ContentFormat()
+		if !ok {
+			t.Fatalf("%d has no content format", f)
+		}
+		if got, err := FormatFromContentFormat(cf); err != nil || got != f {
+			t.Errorf("FormatFromContentFormat(%d) = %d %v, want %d", cf, got, err, f)
+		}
+		if got, err := FormatFromMediaType(f.MediaType()); err != nil || got != f {
+			t.Errorf("FormatFromMediaType(%q) = %d %v, want %d", f.MediaType(), got, err, f)
+		}
+	}
+	if cf, _ := SenSMLCBOR.ContentFormat(); cf != 113 || !SenSMLCBOR.Streaming() || CBOR.Streaming() {
+		t.Fatal("unexpected SenSML CBOR mapping")
+	}
+	if f, err := FormatFromContentFormat(322); err != nil || f != EtchCBOR || f.Streaming() {
+		t.Fatalf("got %d %v, want the etch CBOR format", f, err)
+	}
+	if f, err := FormatFromMediaType("Application/SenML+JSON; charset=utf-8"); err != nil || f != JSON {
+		t.Fatalf("got %d %v", f, err)
+	}
+	if _, ok := PROTO.ContentFormat(); ok || PROTO.MediaType() != "" {
+		t.Fatal("PROTO has no registered media type")
+	}
+	if _, err := FormatFromContentFormat(50); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Fatalf("got %v, want %v", err, ErrUnsupportedFormat)
+	}
+
+	v := 1.0
+	p := Pack{Records: []Record{{Name: "a", Value: &v}}}
+	b, err := Encode(p, SenSMLCBOR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Decode(b, SenSMLCBOR); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -5,6 +5,7 @@ go 1.19
 require (
 	github.com/flywave/go-pbf v0.0.0-20230306063816-5e5b0da27bbd
 	github.com/fxamacker/cbor v1.5.1
+	google.golang.org/protobuf v1.33.0
 )
 
 require github.com/x448/float16 v0.8.4 // indirect
@@ -0,0 +1,5 @@
+// Package senmlpb holds the code generated from senml.proto. It is used to
+// check that the PROTO format of msgtypes matches the published schema.
+package senmlpb
+
+//go:generate protoc -I ../.. --go_out=. --go_opt=paths=source_relative ../../senml.proto
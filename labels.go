@@ -0,0 +1,274 @@
+package msgtypes
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fxamacker/cbor"
+)
+
+// record has the fields of Record without its custom (un)marshalers.
+type record Record
+
+var knownLabels = map[string]bool{
+	"l": true, "bn": true, "bt": true, "bu": true, "bver": true, "bv": true, "bs": true,
+	"n": true, "u": true, "t": true, "ut": true,
+	"v": true, "vs": true, "vd": true, "vb": true, "vv": true, "ve": true, "s": true,
+}
+
+var knownCBORLabels = map[int64]bool{
+	-6: true, -5: true, -4: true, -3: true, -2: true, -1: true,
+	0: true, 1: true, 2: true, 3: true, 4: true, 5: true, 6: true, 7: true, 8: true, 9: true, 10: true,
+}
+
+func (r Record) MarshalJSON() ([]byte, error) {
+	r.Value = r.floatValue()
+	b, err := json.Marshal(record(r))
+	if err != nil || len(r.Extensions) == 0 && r.DataValue == nil {
+		return b, err
+	}
+	buf := bytes.NewBuffer(b[:len(b)-1])
+	field := func(label string, value interface{}) error {
+		v, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(label)
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+		return nil
+	}
+	if r.DataValue != nil {
+		if err := field("vd", encodeData(r.DataValue)); err != nil {
+			return nil, err
+		}
+	}
+	for _, label := range r.extensionLabels() {
+		if err := field(label, r.Extensions[label]); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (r *Record) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, (*record)(r)); err != nil {
+		return err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	r.DataValue = nil
+	if raw, ok := fields["vd"]; ok {
+		var s *string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return err
+		}
+		if s != nil {
+			d, err := decodeData(*s)
+			if err != nil {
+				return err
+			}
+			r.DataValue = d
+		}
+	}
+	r.Extensions = nil
+	for label, raw := range fields {
+		if knownLabels[label] {
+			continue
+		}
+		var v interface{}
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		if err := r.setExtension(label, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r Record) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	r.Value = r.floatValue()
+	start.Name = xml.Name{Local: "senml"}
+	if r.DataValue != nil {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "vd"}, Value: encodeData(r.DataValue)})
+	}
+	for _, label := range r.extensionLabels() {
+		var s string
+		switch v := r.Extensions[label].(type) {
+		case string:
+			s = v
+		case float64:
+			s = strconv.FormatFloat(v, 'g', -1, 64)
+		default:
+			s = fmt.Sprint(v)
+		}
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: label}, Value: s})
+	}
+	return e.EncodeElement(record(r), start)
+}
+
+func (r *Record) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	if err := d.DecodeElement((*record)(r), &start); err != nil {
+		return err
+	}
+	r.DataValue = nil
+	r.Extensions = nil
+	for _, attr := range start.Attr {
+		if attr.Name.Space == "" && attr.Name.Local == "vd" {
+			var err error
+			if r.DataValue, err = decodeData(attr.Value); err != nil {
+				return err
+			}
+			continue
+		}
+		if attr.Name.Space != "" || attr.Name.Local == "xmlns" || knownLabels[attr.Name.Local] {
+			continue
+		}
+		if err := r.setExtension(attr.Name.Local, attr.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r Record) MarshalCBOR() ([]byte, error) {
+	b, err := cbor.Marshal(record(r), cbor.CanonicalEncOptions())
+	if err != nil || len(r.Extensions) == 0 && r.DecimalValue == nil && r.DataValue == nil {
+		return b, err
+	}
+	var fields map[interface{}]interface{}
+	if err := cbor.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	for label, v := range r.Extensions {
+		fields[cborLabel(label)] = v
+	}
+	if r.DecimalValue != nil {
+		fields[uint64(2)] = cbor.RawMessage(r.DecimalValue.appendCBOR(nil))
+	}
+	if r.DataValue != nil {
+		fields[uint64(8)] = r.DataValue
+	}
+	return cbor.Marshal(fields, cbor.CanonicalEncOptions())
+}
+
+func (r *Record) UnmarshalCBOR(data []byte) error {
+	var fields map[interface{}]interface{}
+	if err := cbor.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	r.DecimalValue = nil
+	if v, ok := fields[uint64(2)].([]interface{}); ok {
+		d, err := decimalFromCBOR(v)
+		if err != nil {
+			return err
+		}
+		delete(fields, uint64(2))
+		if data, err = cbor.Marshal(fields, cbor.CanonicalEncOptions()); err != nil {
+			return err
+		}
+		r.DecimalValue = &d
+	}
+	if err := cbor.Unmarshal(data, (*record)(r)); err != nil {
+		return err
+	}
+	if r.DecimalValue != nil {
+		v := r.DecimalValue.Float()
+		r.Value = &v
+	}
+	// vd is a byte string. Text strings, as written by earlier versions,
+	// are read as base64url.
+	r.DataValue = nil
+	switch v := fields[uint64(8)].(type) {
+	case []byte:
+		r.DataValue = append([]byte{}, v...)
+	case string:
+		d, err := decodeData(v)
+		if err != nil {
+			return err
+		}
+		r.DataValue = d
+	case nil:
+	default:
+		return fmt.Errorf("%w: vd is not a byte string", ErrBadData)
+	}
+	r.Extensions = nil
+	for key, v := range fields {
+		var label string
+		switch k := key.(type) {
+		case string:
+			if k == "l" {
+				continue
+			}
+			label = k
+		case int64:
+			if knownCBORLabels[k] {
+				continue
+			}
+			label = extensionLabel(k)
+		case uint64:
+			if k > math.MaxInt64 {
+				label = strconv.FormatUint(k, 10)
+			} else if knownCBORLabels[int64(k)] {
+				continue
+			} else {
+				label = extensionLabel(int64(k))
+			}
+		default:
+			continue
+		}
+		if err := r.setExtension(label, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Record) setExtension(label string, v interface{}) error {
+	v, err := extensionValue(label, v)
+	if err != nil {
+		return err
+	}
+	if r.Extensions == nil {
+		r.Extensions = make(map[string]interface{})
+	}
+	r.Extensions[label] = v
+	return nil
+}
+
+func (r *Record) extensionLabels() []string {
+	labels := make([]string, 0, len(r.Extensions))
+	for label := range r.Extensions {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	return labels
+}
+
+func encodeData(d []byte) string {
+	return base64.RawURLEncoding.EncodeToString(d)
+}
+
+// decodeData decodes a base64url data value. Padding is tolerated.
+func decodeData(s string) ([]byte, error) {
+	d, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadData, err)
+	}
+	return d, nil
+}
@@ -0,0 +1,222 @@
+package msgtypes
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrBadLink = errors.New("invalid link format")
+
+// WebLink is an entry of the CoRE Link Format (RFC 6690) carried in the l
+// field of a record: a target URI and its parameters in order.
+type WebLink struct {
+	URI    string
+	Params []LinkParam
+}
+
+// LinkParam is a link parameter. Value is empty for parameters without a
+// value, such as "obs".
+type LinkParam struct {
+	Name  string
+	Value string
+}
+
+// Param returns the value of the first parameter called name.
+func (l WebLink) Param(name string) (string, bool) {
+	for _, p := range l.Params {
+		if strings.EqualFold(p.Name, name) {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
+
+func (l WebLink) String() string {
+	var sb strings.Builder
+	sb.WriteByte('<')
+	sb.WriteString(l.URI)
+	sb.WriteByte('>')
+	for _, p := range l.Params {
+		sb.WriteByte(';')
+		sb.WriteString(p.Name)
+		if p.Value == "" {
+			continue
+		}
+		sb.WriteByte('=')
+		if isPtoken(p.Value) {
+			sb.WriteString(p.Value)
+			continue
+		}
+		sb.WriteByte('"')
+		for _, c := range p.Value {
+			if c == '"' || c == '\\' {
+				sb.WriteByte('\\')
+			}
+			sb.WriteRune(c)
+		}
+		sb.WriteByte('"')
+	}
+	return sb.String()
+}
+
+// Links parses the l field of r. It returns nil for records without links.
+func (r *Record) Links() ([]WebLink, error) {
+	if r.Link == "" {
+		return nil, nil
+	}
+	return ParseLinks(r.Link)
+}
+
+// SetLinks sets the l field of r to links in CoRE Link Format.
+func (r *Record) SetLinks(links []WebLink) {
+	r.Link = FormatLinks(links)
+}
+
+// FormatLinks returns links in CoRE Link Format.
+func FormatLinks(links []WebLink) string {
+	s := make([]string, len(links))
+	for i, l := range links {
+		s[i] = l.String()
+	}
+	return strings.Join(s, ",")
+}
+
+// ParseLinks parses a CoRE Link Format document such as
+// `</temp>;rt="temperature";if=sensor,</hum>;obs`. Whitespace around the
+// separators is tolerated.
+func ParseLinks(s string) ([]WebLink, error) {
+	p := linkParser{s: s}
+	var links []WebLink
+	for {
+		p.space()
+		l, err := p.link()
+		if err != nil {
+			return nil, fmt.Errorf("%w at offset %d: %v", ErrBadLink, p.pos, err)
+		}
+		links = append(links, l)
+		p.space()
+		if p.done() {
+			return links, nil
+		}
+		if !p.consume(',') {
+			return nil, fmt.Errorf("%w at offset %d: expected ','", ErrBadLink, p.pos)
+		}
+	}
+}
+
+type linkParser struct {
+	s   string
+	pos int
+}
+
+func (p *linkParser) done() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *linkParser) space() {
+	for !p.done() && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t' || p.s[p.pos] == '\r' || p.s[p.pos] == '\n') {
+		p.pos++
+	}
+}
+
+func (p *linkParser) consume(c byte) bool {
+	if !p.done() && p.s[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *linkParser) link() (WebLink, error) {
+	var l WebLink
+	if !p.consume('<') {
+		return l, errors.New("expected '<'")
+	}
+	end := strings.IndexByte(p.s[p.pos:], '>')
+	if end < 0 {
+		return l, errors.New("missing '>'")
+	}
+	l.URI = p.s[p.pos : p.pos+end]
+	p.pos += end + 1
+	for {
+		p.space()
+		if !p.consume(';') {
+			return l, nil
+		}
+		p.space()
+		param, err := p.param()
+		if err != nil {
+			return l, err
+		}
+		l.Params = append(l.Params, param)
+	}
+}
+
+func (p *linkParser) param() (LinkParam, error) {
+	var param LinkParam
+	start := p.pos
+	for !p.done() && isParmnameChar(p.s[p.pos]) {
+		p.pos++
+	}
+	if p.pos == start {
+		return param, errors.New("expected parameter name")
+	}
+	param.Name = p.s[start:p.pos]
+	p.space()
+	if !p.consume('=') {
+		return param, nil
+	}
+	p.space()
+	if p.consume('"') {
+		var sb strings.Builder
+		for {
+			if p.done() {
+				return param, errors.New("unterminated quoted string")
+			}
+			c := p.s[p.pos]
+			p.pos++
+			if c == '"' {
+				break
+			}
+			if c == '\\' {
+				if p.done() {
+					return param, errors.New("unterminated quoted string")
+				}
+				c = p.s[p.pos]
+				p.pos++
+			}
+			sb.WriteByte(c)
+		}
+		param.Value = sb.String()
+		return param, nil
+	}
+	start = p.pos
+	for !p.done() && isPtokenChar(p.s[p.pos]) {
+		p.pos++
+	}
+	if p.pos == start {
+		return param, errors.New("expected parameter value")
+	}
+	param.Value = p.s[start:p.pos]
+	return param, nil
+}
+
+func isParmnameChar(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		strings.IndexByte("!#$&+-.^_`|~*", c) >= 0
+}
+
+func isPtokenChar(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		strings.IndexByte("!#$%&'()*+-./:<=>?@[]^_`{|}~", c) >= 0
+}
+
+func isPtoken(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isPtokenChar(s[i]) {
+			return false
+		}
+	}
+	return s != ""
+}
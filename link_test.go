@@ -0,0 +1,50 @@
+package msgtypes
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestLinks(t *testing.T) {
+	s := `</sensors/temp>;rt="temperature-c";if=sensor;obs, </t>;anchor="/sensors/temp";rel="describedby;x"`
+	links, err := ParseLinks(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []WebLink{
+		{URI: "/sensors/temp", Params: []LinkParam{{"rt", "temperature-c"}, {"if", "sensor"}, {"obs", ""}}},
+		{URI: "/t", Params: []LinkParam{{"anchor", "/sensors/temp"}, {"rel", "describedby;x"}}},
+	}
+	if !reflect.DeepEqual(links, want) {
+		t.Fatalf("got %+v, want %+v", links, want)
+	}
+	if rt, ok := links[0].Param("RT"); !ok || rt != "temperature-c" {
+		t.Fatalf("got %q %v", rt, ok)
+	}
+	if got := FormatLinks(links); got != `</sensors/temp>;rt=temperature-c;if=sensor;obs,</t>;anchor=/sensors/temp;rel="describedby;x"` {
+		t.Fatalf("unexpected format %s", got)
+	}
+	for _, bad := range []string{"", "/temp", "</temp", `</temp>;rt="x`, "</a>;", "</a> </b>", "</a>;rt="} {
+		if _, err := ParseLinks(bad); !errors.Is(err, ErrBadLink) {
+			t.Errorf("ParseLinks(%q) = %v", bad, err)
+		}
+	}
+
+	v := 1.0
+	p := Pack{Records: []Record{{Name: "temp", Value: &v}}}
+	p.Records[0].SetLinks(want)
+	for _, format := range []Format{JSON, XML, CBOR, PROTO, EXI} {
+		b, err := Encode(p, format)
+		if err != nil {
+			t.Fatal(format, err)
+		}
+		q, err := Decode(b, format)
+		if err != nil {
+			t.Fatal(format, err)
+		}
+		if got, err := q.Records[0].Links(); err != nil || !reflect.DeepEqual(got, want) || q.Records[0].Extensions != nil {
+			t.Fatalf("%d: got %+v %v", format, q.Records[0], err)
+		}
+	}
+}
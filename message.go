@@ -5,7 +5,10 @@ import (
 	"encoding/xml"
 	"errors"
 	"io"
+	"math"
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/flywave/go-pbf"
 	"github.com/fxamacker/cbor"
@@ -23,6 +26,18 @@ const (
 	XML
 	CBOR
 	PROTO
+
+	// SenSML streaming variants, encoded like the SenML formats above.
+	SenSMLJSON
+	SenSMLXML
+	SenSMLCBOR
+
+	EXI
+	SenSMLEXI
+
+	// RFC 8790 FETCH and iPATCH packs, encoded like JSON and CBOR.
+	EtchJSON
+	EtchCBOR
 )
 
 var (
@@ -32,11 +47,16 @@ var (
 	ErrBadChar           = errors.New("invalid char")
 	ErrTooManyValues     = errors.New("more than one value in the record")
 	ErrNoValues          = errors.New("no value or sum field found")
+	ErrBadVersion        = errors.New("unsupported version")
+	ErrNotFinite         = errors.New("number is not finite")
+	ErrNegativeUpdate    = errors.New("negative update time")
+	ErrBadData           = errors.New("data value is not base64url")
+	ErrMustUnderstand    = errors.New("unknown must-understand field")
 )
 
 type Record struct {
 	XMLName     *bool      `json:"-" xml:"senml" cbor:"-"`
-	Link        string     `json:"l,omitempty"  xml:"l,attr,omitempty" cbor:"-"`
+	Link        string     `json:"l,omitempty"  xml:"l,attr,omitempty" cbor:"l,omitempty"`
 	BaseName    string     `json:"bn,omitempty" xml:"bn,attr,omitempty" cbor:"-2,keyasint,omitempty"`
 	BaseTime    float64    `json:"bt,omitempty" xml:"bt,attr,omitempty" cbor:"-3,keyasint,omitempty"`
 	BaseUnit    string     `json:"bu,omitempty" xml:"bu,attr,omitempty" cbor:"-4,keyasint,omitempty"`
@@ -49,11 +69,24 @@ type Record struct {
 	UpdateTime  float64    `json:"ut,omitempty" xml:"ut,attr,omitempty" cbor:"7,keyasint,omitempty"`
 	Value       *float64   `json:"v,omitempty" xml:"v,attr,omitempty" cbor:"2,keyasint,omitempty"`
 	StringValue *string    `json:"vs,omitempty" xml:"vs,attr,omitempty" cbor:"3,keyasint,omitempty"`
-	DataValue   *string    `json:"vd,omitempty" xml:"vd,attr,omitempty" cbor:"8,keyasint,omitempty"`
 	BoolValue   *bool      `json:"vb,omitempty" xml:"vb,attr,omitempty" cbor:"4,keyasint,omitempty"`
 	VectorValue *[]float64 `json:"vv,omitempty" xml:"vv,attr,omitempty" cbor:"9,keyasint,omitempty"`
 	EnumValue   *[]string  `json:"ve,omitempty" xml:"ve,attr,omitempty" cbor:"10,keyasint,omitempty"`
 	Sum         *float64   `json:"s,omitempty" xml:"s,attr,omitempty" cbor:"5,keyasint,omitempty"`
+
+	// DataValue, the vd field, is set when not nil, even if empty. It is
+	// carried as base64url without padding in JSON, XML and EXI, and as
+	// raw bytes in CBOR and PROTO.
+	DataValue []byte `json:"-" xml:"-" cbor:"-"`
+
+	// DecimalValue is the exact form of Value. It is carried as a CBOR
+	// decimal fraction (tag 4); the other formats carry its float value.
+	DecimalValue *Decimal `json:"-" xml:"-" cbor:"-"`
+
+	// Extensions holds the fields without a dedicated struct field, keyed
+	// by label. Integer CBOR labels without a registered extension are keyed
+	// by their decimal string.
+	Extensions map[string]interface{} `json:"-" xml:"-" cbor:"-"`
 }
 
 func (r *Record) ToJson() string {
@@ -67,6 +100,47 @@ func RecordFromJson(data io.Reader) *Record {
 	return o
 }
 
+// floatValue returns Value, falling back to the float form of DecimalValue.
+func (r *Record) floatValue() *float64 {
+	if r.Value == nil && r.DecimalValue != nil {
+		v := r.DecimalValue.Float()
+		return &v
+	}
+	return r.Value
+}
+
+// Timestamp returns the time of a resolved record, see NormalizeAt.
+func (r *Record) Timestamp() time.Time {
+	return numericToTime(r.Time)
+}
+
+// SetTimestamp sets the time of the record to the absolute time t.
+func (r *Record) SetTimestamp(t time.Time) {
+	r.Time = numericToFloat64(timeToNumeric(t))
+}
+
+// UpdateInterval returns the update time of the record as a duration.
+func (r *Record) UpdateInterval() time.Duration {
+	return numericToDuration(r.UpdateTime)
+}
+
+// NextUpdate returns the time by which a resolved record promises an
+// updated reading: its timestamp plus its update time. ok is false for
+// records without an update time.
+func (r *Record) NextUpdate() (next time.Time, ok bool) {
+	if r.UpdateTime <= 0 {
+		return time.Time{}, false
+	}
+	return r.Timestamp().Add(r.UpdateInterval()), true
+}
+
+// Overdue reports whether the update promised by a resolved record is
+// later than now. Records without an update time are never overdue.
+func (r *Record) Overdue(now time.Time) bool {
+	next, ok := r.NextUpdate()
+	return ok && now.After(next)
+}
+
 type Records []Record
 
 func (p Records) Len() int {
@@ -81,115 +155,368 @@ func (p Records) Swap(i, j int) {
 	p[i], p[j] = p[j], p[i]
 }
 
+// Normalize validates p and resolves it: base fields are applied to every
+// following record until they change (RFC 8428 section 4.6), and records
+// are sorted by time, keeping the order of records with equal times. The
+// records of p, including the values they point to, are not modified.
 func Normalize(p Pack) (Pack, error) {
 	if err := Validate(p); err != nil {
 		return Pack{}, err
 	}
-	records := make([]Record, len(p.Records))
-	var bname string
-	var btime float64
-	var bsum float64
-	var bunit string
+	return resolve(p), nil
+}
 
+// resolve applies the base fields of p to its records without validating.
+func resolve(p Pack) Pack {
+	records := make([]Record, len(p.Records))
+	var s resolver
 	for i, r := range p.Records {
-		if r.BaseTime != 0 {
-			btime = r.BaseTime
-		}
-		if r.BaseSum != 0 {
-			bsum = r.BaseSum
-		}
-		if r.BaseUnit != "" {
-			bunit = r.BaseUnit
-		}
-		if len(r.BaseName) > 0 {
-			bname = r.BaseName
+		records[i] = s.resolve(r)
+	}
+	p.Records = records
+	sort.Stable(&p)
+	return p
+}
+
+// resolver holds the base fields in effect while the records of a pack are
+// resolved in order.
+type resolver struct {
+	bname  string
+	btime  float64
+	bsum   float64
+	bvalue float64
+	bunit  string
+}
+
+// resolve applies the base fields in effect to r and clears its own.
+func (s *resolver) resolve(r Record) Record {
+	if r.BaseTime != 0 {
+		s.btime = r.BaseTime
+	}
+	if r.BaseSum != 0 {
+		s.bsum = r.BaseSum
+	}
+	if r.BaseValue != 0 {
+		s.bvalue = r.BaseValue
+	}
+	if r.BaseUnit != "" {
+		s.bunit = r.BaseUnit
+	}
+	if len(r.BaseName) > 0 {
+		s.bname = r.BaseName
+	}
+	r.Name = s.bname + r.Name
+	r.Time = s.btime + r.Time
+	if r.Sum != nil {
+		sum := s.bsum + *r.Sum
+		r.Sum = &sum
+	}
+	if r.Unit == "" {
+		r.Unit = s.bunit
+	}
+	if r.Value != nil {
+		v := s.bvalue + *r.Value
+		r.Value = &v
+	}
+	if r.DecimalValue != nil && s.bvalue != 0 {
+		// Add the base value exactly, or as a float if it has no
+		// decimal form.
+		b, err := NumericToDecimal(s.bvalue)
+		if err == nil {
+			var d Decimal
+			if d, err = b.Add(*r.DecimalValue); err == nil {
+				r.DecimalValue = &d
+			}
 		}
-		r.Name = bname + r.Name
-		r.Time = btime + r.Time
-		if r.Sum != nil {
-			*r.Sum = bsum + *r.Sum
+		if err != nil {
+			if r.Value == nil {
+				v := s.bvalue + r.DecimalValue.Float()
+				r.Value = &v
+			}
+			r.DecimalValue = nil
 		}
-		if r.Unit == "" {
-			r.Unit = bunit
+	}
+	if r.BaseVersion == defaultVersion {
+		r.BaseVersion = 0
+	}
+
+	r.BaseTime = 0
+	r.BaseValue = 0
+	r.BaseUnit = ""
+	r.BaseName = ""
+	r.BaseSum = 0
+	return r
+}
+
+// NormalizeAt resolves p like Normalize and then turns every time into an
+// absolute one following RFC 8428 section 4.5.3: times of 2**28 seconds or
+// more are absolute, anything smaller, zero included, is relative to now.
+func NormalizeAt(p Pack, now time.Time) (Pack, error) {
+	return NormalizeOptions{Now: now}.Normalize(p)
+}
+
+// NormalizeOptions selects the rewrites applied on top of Normalize.
+type NormalizeOptions struct {
+	// Now, when not zero, is used to resolve relative times as NormalizeAt.
+	Now time.Time
+	// PrimaryUnits converts values in secondary units, such as kWh, into
+	// their RFC 8798 primary unit.
+	PrimaryUnits bool
+	// RecommendedUnits converts values in units that are not recommended,
+	// such as % or g, into their recommended replacement.
+	RecommendedUnits bool
+	// Units is the registry used for unit rewrites, DefaultUnits if nil.
+	Units *UnitRegistry
+	// Warn, if set, is called for every record using a unit that is not
+	// recommended when RecommendedUnits is set.
+	Warn func(UnitWarning)
+}
+
+// Normalize resolves p and applies the selected rewrites.
+func (o NormalizeOptions) Normalize(p Pack) (Pack, error) {
+	p, err := Normalize(p)
+	if err != nil {
+		return Pack{}, err
+	}
+	if !o.Now.IsZero() {
+		for i := range p.Records {
+			r := &p.Records[i]
+			r.SetTimestamp(parseTime(r.Time, nil, o.Now))
 		}
-		if r.Value != nil && r.BaseValue != 0 {
-			*r.Value = r.BaseValue + *r.Value
+		sort.Stable(&p)
+	}
+	units := unitRegistry(o.Units)
+	for i := range p.Records {
+		r := &p.Records[i]
+		if o.RecommendedUnits {
+			from := Unit(r.Unit)
+			if to, deprecated := r.toRecommendedUnit(units); deprecated && o.Warn != nil {
+				o.Warn(UnitWarning{Index: i, Name: r.Name, Unit: from, Replacement: to})
+			}
 		}
-		if r.BaseVersion == defaultVersion {
-			r.BaseVersion = 0
+		if o.PrimaryUnits {
+			r.toPrimaryUnit(units)
 		}
-
-		r.BaseTime = 0
-		r.BaseValue = 0
-		r.BaseUnit = ""
-		r.BaseName = ""
-		r.BaseSum = 0
-		records[i] = r
 	}
-	p.Records = records
-	sort.Sort(&p)
 	return p, nil
 }
 
+// ValidationOptions selects how strictly packs are validated. The zero value
+// applies every rule of RFC 8428.
+type ValidationOptions struct {
+	// Lenient only checks names, values and version changes, skipping the
+	// must-understand, base version and finiteness rules.
+	Lenient bool
+	// AllowBaseOnly accepts records that carry nothing but base fields.
+	AllowBaseOnly bool
+	// Understood lists the must-understand labels, ending in '_', that the
+	// application handles itself.
+	Understood []string
+	// KnownUnits rejects units in bu and u that are not registered.
+	KnownUnits bool
+	// UnitExpressions makes KnownUnits also accept expressions of
+	// registered units that ParseUnit accepts, such as "kg.m/s2".
+	UnitExpressions bool
+	// Units is the registry used for KnownUnits, DefaultUnits if nil.
+	Units *UnitRegistry
+	// AllowNoValue accepts records without a value or sum, as used by
+	// iPATCH to remove records.
+	AllowNoValue bool
+}
+
 func Validate(p Pack) error {
-	var bver uint
-	var bname string
-	var bsum float64
+	return ValidationOptions{}.Validate(p)
+}
+
+// ValidateAll validates every record of p and returns all problems found as
+// ValidationErrors, or nil if the pack is valid.
+func ValidateAll(p Pack) error {
+	return ValidationOptions{}.ValidateAll(p)
+}
+
+// Validate returns the first error found in p.
+func (o ValidationOptions) Validate(p Pack) error {
+	v := validator{opts: o}
 	for _, r := range p.Records {
-		if bver == 0 && r.BaseVersion != 0 {
-			bver = r.BaseVersion
-		}
-		if bver != 0 && r.BaseVersion == 0 {
-			r.BaseVersion = bver
-		}
-		if r.BaseVersion != bver {
-			return ErrVersionChange
-		}
-		if r.BaseName != "" {
-			bname = r.BaseName
-		}
-		if r.BaseSum != 0 {
-			bsum = r.BaseSum
-		}
-		name := bname + r.Name
-		if len(name) == 0 {
-			return ErrEmptyName
+		if err := v.validate(r); err != nil {
+			return err
 		}
-		var valCnt int
-		if r.Value != nil {
-			valCnt++
+	}
+	return nil
+}
+
+// ValidateAll returns every error found in p as ValidationErrors.
+func (o ValidationOptions) ValidateAll(p Pack) error {
+	v := validator{opts: o}
+	var errs ValidationErrors
+	for _, r := range p.Records {
+		if err := v.validate(r); err != nil {
+			errs = append(errs, err.(*ValidationError))
 		}
-		if r.BoolValue != nil {
-			valCnt++
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+type validator struct {
+	opts  ValidationOptions
+	n     int
+	bver  uint
+	bname string
+	bsum  float64
+}
+
+func (v *validator) validate(r Record) error {
+	index := v.n
+	v.n++
+	if r.BaseName != "" {
+		v.bname = r.BaseName
+	}
+	if r.BaseSum != 0 {
+		v.bsum = r.BaseSum
+	}
+	name := v.bname + r.Name
+	fail := func(field string, err error) error {
+		return &ValidationError{Index: index, Name: name, Field: field, Err: err}
+	}
+
+	if !v.opts.Lenient {
+		for label := range r.Extensions {
+			if strings.HasSuffix(label, "_") && !v.understood(label) {
+				return fail(label, ErrMustUnderstand)
+			}
 		}
-		if r.DataValue != nil {
-			valCnt++
+		if r.BaseVersion != 0 && r.BaseVersion < defaultVersion {
+			return fail("bver", ErrBadVersion)
 		}
-		if r.StringValue != nil {
-			valCnt++
+		if field := nonFinite(&r); field != "" {
+			return fail(field, ErrNotFinite)
 		}
-		if r.VectorValue != nil {
-			valCnt++
+	}
+	if r.UpdateTime < 0 {
+		return fail("ut", ErrNegativeUpdate)
+	}
+	if v.opts.KnownUnits {
+		units := unitRegistry(v.opts.Units)
+		known := func(u string) bool {
+			if _, ok := units.Lookup(Unit(u)); ok {
+				return true
+			}
+			_, err := units.Parse(u)
+			return v.opts.UnitExpressions && err == nil
 		}
-		if r.EnumValue != nil {
-			valCnt++
+		if !known(r.BaseUnit) {
+			return fail("bu", ErrUnknownUnit)
 		}
-		if valCnt > 1 {
-			return ErrTooManyValues
+		if !known(r.Unit) {
+			return fail("u", ErrUnknownUnit)
 		}
-		if r.Sum != nil || bsum != 0 {
-			valCnt++
+	}
+	if v.bver == 0 && r.BaseVersion != 0 {
+		v.bver = r.BaseVersion
+	}
+	if v.bver != 0 && r.BaseVersion == 0 {
+		r.BaseVersion = v.bver
+	}
+	if r.BaseVersion != v.bver {
+		return fail("bver", ErrVersionChange)
+	}
+	if v.opts.AllowBaseOnly && baseOnly(&r) {
+		if r.BaseName != "" {
+			if err := validateName(r.BaseName); err != nil {
+				return fail("bn", err)
+			}
 		}
-		if valCnt < 1 {
-			return ErrNoValues
+		return nil
+	}
+	if len(name) == 0 {
+		return fail("n", ErrEmptyName)
+	}
+	var values []string
+	if r.Value != nil || r.DecimalValue != nil {
+		values = append(values, "v")
+	}
+	if r.BoolValue != nil {
+		values = append(values, "vb")
+	}
+	if r.DataValue != nil {
+		values = append(values, "vd")
+	}
+	if r.StringValue != nil {
+		values = append(values, "vs")
+	}
+	if r.VectorValue != nil {
+		values = append(values, "vv")
+	}
+	if r.EnumValue != nil {
+		values = append(values, "ve")
+	}
+	if len(values) > 1 {
+		return fail(values[1], ErrTooManyValues)
+	}
+	if len(values) == 0 && r.Sum == nil && v.bsum == 0 && !v.opts.AllowNoValue {
+		return fail("v", ErrNoValues)
+	}
+	if err := validateName(name); err != nil {
+		return fail("n", err)
+	}
+	return nil
+}
+
+// baseOnly reports whether r carries base fields but no regular fields.
+func baseOnly(r *Record) bool {
+	return r.Name == "" && r.Unit == "" && r.Time == 0 && r.UpdateTime == 0 &&
+		r.Value == nil && r.DecimalValue == nil && r.StringValue == nil && r.DataValue == nil && r.BoolValue == nil &&
+		r.VectorValue == nil && r.EnumValue == nil && r.Sum == nil
+}
+
+func (v *validator) understood(label string) bool {
+	if _, ok := LookupExtension(label); ok {
+		return true
+	}
+	for _, l := range v.opts.Understood {
+		if l == label {
+			return true
 		}
-		if err := validateName(name); err != nil {
-			return err
+	}
+	return false
+}
+
+// nonFinite returns the label of the first NaN or infinite number in r.
+func nonFinite(r *Record) string {
+	finite := func(f float64) bool {
+		return !math.IsNaN(f) && !math.IsInf(f, 0)
+	}
+	switch {
+	case !finite(r.BaseTime):
+		return "bt"
+	case !finite(r.BaseValue):
+		return "bv"
+	case !finite(r.BaseSum):
+		return "bs"
+	case !finite(r.Time):
+		return "t"
+	case !finite(r.UpdateTime):
+		return "ut"
+	case r.Value != nil && !finite(*r.Value):
+		return "v"
+	case r.Sum != nil && !finite(*r.Sum):
+		return "s"
+	}
+	if r.VectorValue != nil {
+		for _, f := range *r.VectorValue {
+			if !finite(f) {
+				return "vv"
+			}
 		}
 	}
-	return nil
+	return ""
 }
 
+// validateName checks the RFC 8428 name rules: only the characters
+// A-Z, a-z, 0-9, "-", ":", ".", "/" and "_", starting with a letter or digit.
 func validateName(name string) error {
 	l := name[0]
 	if (l == '-') || (l == ':') || (l == '.') || (l == '/') || (l == '_') {
@@ -220,85 +547,236 @@ const (
 	BoolValueTag   pbf.TagType = 14
 	SumTag         pbf.TagType = 15
 	VectorValueTag pbf.TagType = 16
-	EnumValueTag   pbf.TagType = 17
+	ExtensionTag   pbf.TagType = 18
+	LinkTag        pbf.TagType = 19
+	EnumValueTag   pbf.TagType = 20
+
+	// PackedEnumValueTag holds enumeration values as one packed field, as
+	// written by earlier versions. It is decoded but no longer written.
+	PackedEnumValueTag pbf.TagType = 17
+
+	ExtensionLabelTag  pbf.TagType = 1
+	ExtensionNumberTag pbf.TagType = 2
+	ExtensionStringTag pbf.TagType = 3
+	ExtensionBoolTag   pbf.TagType = 4
+	ExtensionJSONTag   pbf.TagType = 5
 
 	RecordsTag pbf.TagType = 1
 )
 
-func decodeRecordfunc(key pbf.TagType, val pbf.WireType, result interface{}, reader *pbf.Reader) {
-	record := result.(*Record)
-	if key == BaseNameTag && val == pbf.Bytes {
-		record.BaseName = reader.ReadString()
-	}
-	if key == BaseTimeTag && val == pbf.Fixed64 {
-		record.BaseTime = reader.ReadDouble()
-	}
-	if key == BaseUnitTag && val == pbf.Bytes {
-		record.BaseUnit = reader.ReadString()
-	}
-	if key == BaseVersionTag && val == pbf.Varint {
-		record.BaseVersion = uint(reader.ReadUInt64())
-	}
-	if key == BaseValueTag && val == pbf.Fixed64 {
-		record.BaseValue = reader.ReadDouble()
-	}
-	if key == BaseSumTag && val == pbf.Fixed64 {
-		record.BaseSum = reader.ReadDouble()
-	}
-	if key == NameTag && val == pbf.Bytes {
-		record.Name = reader.ReadString()
-	}
-	if key == UnitTag && val == pbf.Bytes {
-		record.Unit = reader.ReadString()
-	}
-	if key == TimeTag && val == pbf.Fixed64 {
-		record.Time = reader.ReadDouble()
-	}
-	if key == UpdateTimeTag && val == pbf.Fixed64 {
-		record.UpdateTime = reader.ReadDouble()
-	}
-	if key == ValueTag && val == pbf.Fixed64 {
-		v := reader.ReadDouble()
-		record.Value = &v
-	}
-	if key == StringValueTag && val == pbf.Bytes {
-		v := reader.ReadString()
-		record.StringValue = &v
-	}
-	if key == DataValueTag && val == pbf.Bytes {
-		v := reader.ReadString()
-		record.DataValue = &v
-	}
-	if key == BoolValueTag && val == pbf.Varint {
-		v := reader.ReadBool()
-		record.BoolValue = &v
-	}
-	if key == SumTag && val == pbf.Fixed64 {
-		v := reader.ReadDouble()
-		record.Sum = &v
-	}
-	if key == VectorValueTag && val == pbf.Bytes {
-		v := reader.ReadPackedDouble()
-		record.VectorValue = &v
-	}
-	if key == EnumValueTag && val == pbf.Bytes {
-		v := reader.ReadPackedString()
-		record.EnumValue = &v
-	}
+func decodeRecord(r *protoReader, record *Record) error {
+	return r.fields(func(key pbf.TagType, val pbf.WireType) error {
+		var err error
+		str := func(s *string) {
+			var b []byte
+			if b, err = r.bytes(); err == nil {
+				*s = string(b)
+			}
+		}
+		double := func(f *float64) {
+			*f, err = r.double()
+		}
+		want := pbf.Bytes
+		switch key {
+		case BaseTimeTag, BaseValueTag, BaseSumTag, TimeTag, UpdateTimeTag, ValueTag, SumTag:
+			want = pbf.Fixed64
+		case BaseVersionTag, BoolValueTag:
+			want = pbf.Varint
+		case VectorValueTag:
+			if val == pbf.Fixed64 {
+				want = val
+			}
+		case BaseNameTag, BaseUnitTag, NameTag, UnitTag, StringValueTag, DataValueTag, EnumValueTag, PackedEnumValueTag, ExtensionTag, LinkTag:
+		default:
+			return r.skip(val)
+		}
+		if val != want {
+			return ErrProtoWireType
+		}
+
+		switch key {
+		case BaseNameTag:
+			str(&record.BaseName)
+		case BaseTimeTag:
+			double(&record.BaseTime)
+		case BaseUnitTag:
+			str(&record.BaseUnit)
+		case BaseVersionTag:
+			var v uint64
+			if v, err = r.varint(); err == nil {
+				record.BaseVersion = uint(v)
+			}
+		case BaseValueTag:
+			double(&record.BaseValue)
+		case BaseSumTag:
+			double(&record.BaseSum)
+		case NameTag:
+			str(&record.Name)
+		case UnitTag:
+			str(&record.Unit)
+		case TimeTag:
+			double(&record.Time)
+		case UpdateTimeTag:
+			double(&record.UpdateTime)
+		case ValueTag:
+			record.Value = new(float64)
+			double(record.Value)
+		case StringValueTag:
+			record.StringValue = new(string)
+			str(record.StringValue)
+		case DataValueTag:
+			var b []byte
+			if b, err = r.bytes(); err == nil {
+				record.DataValue = append([]byte{}, b...)
+			}
+		case BoolValueTag:
+			var v uint64
+			if v, err = r.varint(); err == nil {
+				b := v != 0
+				record.BoolValue = &b
+			}
+		case SumTag:
+			record.Sum = new(float64)
+			double(record.Sum)
+		case VectorValueTag:
+			var v []float64
+			if record.VectorValue != nil {
+				v = *record.VectorValue
+			}
+			if val == pbf.Fixed64 {
+				var f float64
+				double(&f)
+				v = append(v, f)
+			} else {
+				var packed *protoReader
+				if packed, err = r.message(); err == nil {
+					if len(packed.buf)%8 != 0 {
+						return ErrProtoLength
+					}
+					for !packed.done() {
+						f, _ := packed.double()
+						v = append(v, f)
+					}
+				}
+			}
+			record.VectorValue = &v
+		case EnumValueTag:
+			var s string
+			str(&s)
+			var v []string
+			if record.EnumValue != nil {
+				v = *record.EnumValue
+			}
+			v = append(v, s)
+			record.EnumValue = &v
+		case PackedEnumValueTag:
+			var v []string
+			if record.EnumValue != nil {
+				v = *record.EnumValue
+			}
+			var packed *protoReader
+			if packed, err = r.message(); err == nil {
+				for !packed.done() {
+					var b []byte
+					if b, err = packed.bytes(); err != nil {
+						break
+					}
+					v = append(v, string(b))
+				}
+			}
+			record.EnumValue = &v
+		case LinkTag:
+			str(&record.Link)
+		case ExtensionTag:
+			var m *protoReader
+			if m, err = r.message(); err != nil {
+				return err
+			}
+			ext := &protoExtension{}
+			if err = decodeExtension(m, ext); err != nil {
+				return err
+			}
+			if ext.label != "" {
+				err = record.setExtension(ext.label, ext.value)
+			}
+		}
+		return err
+	})
 }
 
-func decodeProto(bytevals []byte) (records Records, err error) {
-	r := &pbf.Reader{Pbf: bytevals, Length: len(bytevals)}
+type protoExtension struct {
+	label string
+	value interface{}
+}
 
-	records = Records{}
+func decodeExtension(r *protoReader, ext *protoExtension) error {
+	return r.fields(func(key pbf.TagType, val pbf.WireType) error {
+		want := pbf.Bytes
+		switch key {
+		case ExtensionNumberTag:
+			want = pbf.Fixed64
+		case ExtensionBoolTag:
+			want = pbf.Varint
+		case ExtensionLabelTag, ExtensionStringTag, ExtensionJSONTag:
+		default:
+			return r.skip(val)
+		}
+		if val != want {
+			return ErrProtoWireType
+		}
+		switch key {
+		case ExtensionNumberTag:
+			f, err := r.double()
+			ext.value = f
+			return err
+		case ExtensionBoolTag:
+			v, err := r.varint()
+			ext.value = v != 0
+			return err
+		}
+		b, err := r.bytes()
+		if err != nil {
+			return err
+		}
+		switch key {
+		case ExtensionLabelTag:
+			ext.label = string(b)
+		case ExtensionStringTag:
+			ext.value = string(b)
+		case ExtensionJSONTag:
+			var v interface{}
+			if err := json.Unmarshal(b, &v); err != nil {
+				return err
+			}
+			ext.value = v
+		}
+		return nil
+	})
+}
 
-	for r.Pos < r.Length {
-		key, val := r.ReadTag()
-		if key == RecordsTag && val == pbf.Bytes {
-			record := &Record{}
-			r.ReadMessage(decodeRecordfunc, record)
-			records = append(records, *record)
+func decodeProto(bytevals []byte) (Records, error) {
+	r := &protoReader{buf: bytevals}
+	records := Records{}
+	err := r.fields(func(key pbf.TagType, val pbf.WireType) error {
+		if key != RecordsTag {
+			return r.skip(val)
+		}
+		if val != pbf.Bytes {
+			return ErrProtoWireType
+		}
+		m, err := r.message()
+		if err != nil {
+			return err
+		}
+		var record Record
+		if err := decodeRecord(m, &record); err != nil {
+			return err
 		}
+		records = append(records, record)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	return records, nil
 }
@@ -307,30 +785,42 @@ func encodeRecord(writer *pbf.Writer, record *Record) error {
 	if record.BaseName != "" {
 		writer.WriteString(BaseNameTag, record.BaseName)
 	}
-	writer.WriteDouble(BaseTimeTag, record.BaseTime)
+	if record.BaseTime != 0 {
+		writer.WriteDouble(BaseTimeTag, record.BaseTime)
+	}
 	if record.BaseUnit != "" {
 		writer.WriteString(BaseUnitTag, record.BaseUnit)
 	}
-	writer.WriteUInt64(BaseVersionTag, uint64(record.BaseVersion))
-	writer.WriteDouble(BaseValueTag, record.BaseValue)
-	writer.WriteDouble(BaseSumTag, record.BaseSum)
+	if record.BaseVersion != 0 {
+		writer.WriteUInt64(BaseVersionTag, uint64(record.BaseVersion))
+	}
+	if record.BaseValue != 0 {
+		writer.WriteDouble(BaseValueTag, record.BaseValue)
+	}
+	if record.BaseSum != 0 {
+		writer.WriteDouble(BaseSumTag, record.BaseSum)
+	}
 	if record.Name != "" {
 		writer.WriteString(NameTag, record.Name)
 	}
 	if record.Unit != "" {
 		writer.WriteString(UnitTag, record.Unit)
 	}
-	writer.WriteDouble(TimeTag, record.Time)
-	writer.WriteDouble(UpdateTimeTag, record.UpdateTime)
+	if record.Time != 0 {
+		writer.WriteDouble(TimeTag, record.Time)
+	}
+	if record.UpdateTime != 0 {
+		writer.WriteDouble(UpdateTimeTag, record.UpdateTime)
+	}
 
-	if record.Value != nil {
-		writer.WriteDouble(ValueTag, *record.Value)
+	if v := record.floatValue(); v != nil {
+		writer.WriteDouble(ValueTag, *v)
 	}
 	if record.StringValue != nil {
 		writer.WriteString(StringValueTag, *record.StringValue)
 	}
 	if record.DataValue != nil {
-		writer.WriteString(DataValueTag, *record.DataValue)
+		writer.WriteString(DataValueTag, string(record.DataValue))
 	}
 	if record.BoolValue != nil {
 		writer.WriteBool(BoolValueTag, *record.BoolValue)
@@ -342,7 +832,41 @@ func encodeRecord(writer *pbf.Writer, record *Record) error {
 		writer.WritePackedDouble(VectorValueTag, *record.VectorValue)
 	}
 	if record.EnumValue != nil {
-		writer.WritePackedString(EnumValueTag, *record.EnumValue)
+		// A repeated string field, one per value as senml.proto declares.
+		for _, v := range *record.EnumValue {
+			writer.WriteString(EnumValueTag, v)
+		}
+	}
+	if record.Link != "" {
+		writer.WriteString(LinkTag, record.Link)
+	}
+	for _, label := range record.extensionLabels() {
+		var err error
+		writer.WriteMessage(ExtensionTag, func(w *pbf.Writer) {
+			err = encodeExtension(w, label, record.Extensions[label])
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func encodeExtension(writer *pbf.Writer, label string, value interface{}) error {
+	writer.WriteString(ExtensionLabelTag, label)
+	switch v := value.(type) {
+	case string:
+		writer.WriteString(ExtensionStringTag, v)
+	case bool:
+		writer.WriteBool(ExtensionBoolTag, v)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		writer.WriteDouble(ExtensionNumberTag, numericToFloat64(v))
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		writer.WriteString(ExtensionJSONTag, string(b))
 	}
 	return nil
 }
@@ -351,9 +875,13 @@ func encodeProto(records Records) ([]byte, error) {
 	w := pbf.NewWriter()
 
 	for _, record := range records {
+		var err error
 		w.WriteMessage(RecordsTag, func(w *pbf.Writer) {
-			encodeRecord(w, &record)
+			err = encodeRecord(w, &record)
 		})
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return w.Finish(), nil
@@ -378,8 +906,16 @@ func (p *Pack) Swap(i, j int) {
 }
 
 func Decode(msg []byte, format Format) (Pack, error) {
+	p, err := decodePack(msg, format)
+	if err != nil {
+		return Pack{}, err
+	}
+	return p, Validate(p)
+}
+
+func decodePack(msg []byte, format Format) (Pack, error) {
 	var p Pack
-	switch format {
+	switch format.base() {
 	case JSON:
 		if err := json.Unmarshal(msg, &p.Records); err != nil {
 			return Pack{}, err
@@ -398,15 +934,53 @@ func Decode(msg []byte, format Format) (Pack, error) {
 		if p.Records, err = decodeProto(msg); err != nil {
 			return Pack{}, err
 		}
+	case EXI:
+		var err error
+		if p.Records, err = decodeEXI(msg); err != nil {
+			return Pack{}, err
+		}
 	default:
 		return Pack{}, ErrUnsupportedFormat
 	}
+	return p, nil
+}
 
-	return p, Validate(p)
+// EncodeOptions selects the rewrites applied by Encode.
+type EncodeOptions struct {
+	// RecommendedUnits resolves packs using units that are not recommended
+	// and converts their values as NormalizeOptions.RecommendedUnits.
+	RecommendedUnits bool
+	// Units is the registry used for unit rewrites, DefaultUnits if nil.
+	Units *UnitRegistry
+	// Warn, if set, is called for every rewritten record.
+	Warn func(UnitWarning)
+}
+
+// Encode encodes p in format after applying the selected rewrites.
+func (o EncodeOptions) Encode(p Pack, format Format) ([]byte, error) {
+	if o.RecommendedUnits && usesDeprecatedUnits(p, unitRegistry(o.Units)) {
+		var err error
+		p, err = NormalizeOptions{RecommendedUnits: true, Units: o.Units, Warn: o.Warn}.Normalize(p)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return Encode(p, format)
+}
+
+func usesDeprecatedUnits(p Pack, g *UnitRegistry) bool {
+	for _, r := range p.Records {
+		for _, u := range []string{r.BaseUnit, r.Unit} {
+			if info, ok := g.Lookup(Unit(u)); ok && !info.Recommended {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 func Encode(p Pack, format Format) ([]byte, error) {
-	switch format {
+	switch format.base() {
 	case JSON:
 		return json.Marshal(p.Records)
 	case XML:
@@ -416,6 +990,8 @@ func Encode(p Pack, format Format) ([]byte, error) {
 		return cbor.Marshal(p.Records, cbor.CanonicalEncOptions())
 	case PROTO:
 		return encodeProto(p.Records)
+	case EXI:
+		return encodeEXI(p.Records)
 	default:
 		return nil, ErrUnsupportedFormat
 	}
@@ -1,6 +1,12 @@
 package msgtypes
 
-import "testing"
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
 
 func TestEncodeDecode(t *testing.T) {
 	v := 20.6
@@ -31,3 +37,241 @@ func TestEncodeDecode(t *testing.T) {
 	}
 
 }
+
+func TestNormalizeAt(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	v := 1.0
+
+	p := Pack{Records: []Record{
+		{Name: "rel", Time: -5, Value: &v},
+		{Name: "now", Value: &v},
+		{Name: "abs", BaseTime: 1.276020076e+09, Time: 1, Value: &v},
+	}}
+
+	n, err := NormalizeAt(p, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]time.Time{
+		"rel": now.Add(-5 * time.Second),
+		"now": now,
+		"abs": time.Unix(1276020077, 0),
+	}
+	for _, r := range n.Records {
+		if ts := r.Timestamp(); !ts.Equal(want[r.Name]) {
+			t.Fatalf("%s: got %v, want %v", r.Name, ts, want[r.Name])
+		}
+	}
+	if n.Records[0].Name != "abs" {
+		t.Fatalf("records are not sorted by resolved time")
+	}
+}
+
+func TestNormalizeBaseValue(t *testing.T) {
+	v1, v2, s := 1.0, 2.0, 3.0
+	p := Pack{Records: []Record{
+		{BaseValue: 10, BaseSum: 100, Name: "a", Value: &v1},
+		{Name: "b", Value: &v2, Sum: &s},
+	}}
+
+	n, err := Normalize(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *n.Records[0].Value != 11 || *n.Records[1].Value != 12 || *n.Records[1].Sum != 103 {
+		t.Fatalf("base value not carried over: %+v", n.Records)
+	}
+	if v1 != 1 || v2 != 2 || s != 3 {
+		t.Fatal("Normalize modified its input")
+	}
+}
+
+func TestValidateAll(t *testing.T) {
+	v := 1.0
+	s := "x"
+
+	p := Pack{Records: []Record{
+		{BaseName: "dev:", Name: "ok", Value: &v},
+		{Name: "two", Value: &v, StringValue: &s},
+		{Name: "none"},
+	}}
+
+	err := ValidateAll(p)
+	var errs ValidationErrors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if errs[0].Index != 1 || errs[0].Name != "dev:two" || errs[0].Field != "vs" {
+		t.Fatalf("unexpected first error %+v", errs[0])
+	}
+	if !errors.Is(err, ErrNoValues) || !errors.Is(err, ErrTooManyValues) {
+		t.Fatalf("sentinels not found in %v", err)
+	}
+
+	var ve *ValidationError
+	if err := Validate(p); !errors.As(err, &ve) || ve.Index != 1 || !errors.Is(err, ErrTooManyValues) {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestValidationOptions(t *testing.T) {
+	p, err := Decode([]byte(`[{"n":"a","v":1,"rssi_":3}]`), JSON)
+	if !errors.Is(err, ErrMustUnderstand) {
+		t.Fatalf("got %v, want %v", err, ErrMustUnderstand)
+	}
+	if err := (ValidationOptions{Understood: []string{"rssi_"}}).Validate(p); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Decode([]byte(`[{"n":"a","v":1,"rssi":3}]`), JSON); err != nil {
+		t.Fatal(err)
+	}
+
+	nan := math.NaN()
+	p = Pack{Records: []Record{{Name: "a", Value: &nan}}}
+	if err := Validate(p); !errors.Is(err, ErrNotFinite) {
+		t.Fatalf("got %v, want %v", err, ErrNotFinite)
+	}
+	if err := (ValidationOptions{Lenient: true}).Validate(p); err != nil {
+		t.Fatal(err)
+	}
+
+	v := 1.0
+	p = Pack{Records: []Record{{BaseName: "dev:", BaseTime: 1e9}, {Name: "a", Value: &v}}}
+	if err := Validate(p); !errors.Is(err, ErrNoValues) {
+		t.Fatalf("got %v, want %v", err, ErrNoValues)
+	}
+	if err := (ValidationOptions{AllowBaseOnly: true}).Validate(p); err != nil {
+		t.Fatal(err)
+	}
+
+	p = Pack{Records: []Record{{BaseVersion: 5, Name: "a", Value: &v}}}
+	if err := Validate(p); !errors.Is(err, ErrBadVersion) {
+		t.Fatalf("got %v, want %v", err, ErrBadVersion)
+	}
+}
+
+func TestUpdateTime(t *testing.T) {
+	v := 1.0
+	p := Pack{Records: []Record{{Name: "a", Value: &v, UpdateTime: -1}}}
+	for _, opts := range []ValidationOptions{{}, {Lenient: true}} {
+		if err := opts.Validate(p); !errors.Is(err, ErrNegativeUpdate) {
+			t.Fatalf("%+v: got %v, want %v", opts, err, ErrNegativeUpdate)
+		}
+	}
+
+	r := Record{Name: "a", Value: &v}
+	r.SetTimestamp(time.Unix(1700000000, 0))
+	if _, ok := r.NextUpdate(); ok || r.Overdue(time.Unix(1800000000, 0)) {
+		t.Fatal("record without ut has a next update")
+	}
+	r.UpdateTime = 60
+	next, ok := r.NextUpdate()
+	if want := time.Unix(1700000060, 0); !ok || !next.Equal(want) {
+		t.Fatalf("got %v, want %v", next, want)
+	}
+	if r.Overdue(next) {
+		t.Fatal("overdue at the promised time")
+	}
+	if !r.Overdue(next.Add(time.Millisecond)) {
+		t.Fatal("not overdue after the promised time")
+	}
+}
+
+// TestRoundTrip encodes one record per Record field in every format and
+// checks that each field survives unchanged.
+func TestRoundTrip(t *testing.T) {
+	v, s, b, sum := 21.5, "on", true, 3.25
+	vd := []byte{0, 'h', 0xff, 0xfe}
+	vv := []float64{1.5, -2}
+	ve := []string{"one", "two"}
+	d := NewDecimal(-2, 2150)
+	df := d.Float()
+
+	records := []struct {
+		field  string
+		record Record
+	}{
+		{"bn", Record{BaseName: "urn:dev:ow:10e2073a01080063:", Name: "x", Value: &v}},
+		{"bt", Record{BaseTime: 1.320067464e+09, Name: "x", Value: &v}},
+		{"bu", Record{BaseUnit: "Cel", Name: "x", Value: &v}},
+		{"bver", Record{BaseVersion: 10, Name: "x", Value: &v}},
+		{"bv", Record{BaseValue: 100, Name: "x", Value: &v}},
+		{"bs", Record{BaseSum: 7, Name: "x", Sum: &sum}},
+		{"n", Record{Name: "temp", Value: &v}},
+		{"u", Record{Name: "x", Unit: "%RH", Value: &v}},
+		{"t", Record{Name: "x", Time: -5.5, Value: &v}},
+		{"ut", Record{Name: "x", UpdateTime: 60, Value: &v}},
+		{"v", Record{Name: "x", Value: &v}},
+		{"vs", Record{Name: "x", StringValue: &s}},
+		{"vd", Record{Name: "x", DataValue: vd}},
+		{"empty vd", Record{Name: "x", DataValue: []byte{}}},
+		{"vb", Record{Name: "x", BoolValue: &b}},
+		{"vv", Record{Name: "x", VectorValue: &vv}},
+		{"ve", Record{Name: "x", EnumValue: &ve}},
+		{"s", Record{Name: "x", Sum: &sum}},
+		{"l", Record{Name: "x", Link: `</temp>;rt="temperature"`, Value: &v}},
+		{"decimal", Record{Name: "x", DecimalValue: &d}},
+		{"extension", Record{Name: "x", Value: &v, Extensions: map[string]interface{}{"note": "calibrated"}}},
+	}
+
+	for _, format := range []Format{JSON, XML, CBOR, PROTO} {
+		for _, c := range records {
+			want := c.record
+			if c.field == "decimal" {
+				// Decoders set Value; only CBOR keeps the exact form.
+				want = Record{Name: "x", Value: &df}
+				if format == CBOR {
+					want.DecimalValue = &d
+				}
+			}
+			data, err := Encode(Pack{Records: []Record{c.record}}, format)
+			if err != nil {
+				t.Fatalf("%v %s: %v", format, c.field, err)
+			}
+			p, err := Decode(data, format)
+			if err != nil {
+				t.Fatalf("%v %s: %v", format, c.field, err)
+			}
+			if len(p.Records) != 1 || !reflect.DeepEqual(p.Records[0], want) {
+				t.Errorf("%v %s: got %+v, want %+v", format, c.field, p.Records, want)
+			}
+		}
+	}
+}
+
+func TestDataValue(t *testing.T) {
+	p := Pack{Records: []Record{{Name: "x", DataValue: []byte{0, 'h', 0xff, 0xfe}}}}
+	b, err := Encode(p, JSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `[{"n":"x","vd":"AGj__g"}]`; string(b) != want {
+		t.Fatalf("got %s, want %s", b, want)
+	}
+
+	p = Pack{Records: []Record{{Name: "x", DataValue: []byte{1, 2}}}}
+	b, err = Encode(p, CBOR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "\x81\xa2\x00\x61x\x08\x42\x01\x02"; string(b) != want {
+		t.Fatalf("got %x, want %x", b, want)
+	}
+
+	// Text strings written by earlier versions are read as base64url.
+	legacy, err := Decode([]byte("\x81\xa2\x00\x61x\x08\x64AQI="), CBOR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(legacy, p) {
+		t.Fatalf("got %+v, want %+v", legacy, p)
+	}
+
+	if _, err := Decode([]byte(`[{"n":"x","vd":"a+b/"}]`), JSON); !errors.Is(err, ErrBadData) {
+		t.Fatalf("got %v, want %v", err, ErrBadData)
+	}
+	if _, err := Decode([]byte(`<sensml xmlns="urn:ietf:params:xml:ns:senml"><senml n="x" vd="!"></senml></sensml>`), XML); !errors.Is(err, ErrBadData) {
+		t.Fatalf("got %v, want %v", err, ErrBadData)
+	}
+}
@@ -1,198 +1,446 @@
 package msgtypes
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"math/big"
+	"strconv"
 	"time"
 )
 
+// Numeric is any Go integer or float type, Decimal or *Decimal. A nil
+// Numeric is an absent number and acts as zero in arithmetic.
 type Numeric interface{}
 
-func sumNumeric(a, b Numeric) Numeric {
-	switch a.(type) {
-	case nil:
-		return b
-	case int, int8, int16, int32, int64:
-		return addToNumericInt(a, b)
-	case uint, uint8, uint16, uint32, uint64:
-		return addToNumericUint(a, b)
-	case float32, float64, Decimal, *Decimal:
-		return numericToFloat64(a) + numericToFloat64(b)
-	default:
-		panic(fmt.Sprintf("invalid value type: %T", a))
-	}
-}
+// NumericType is the representation arithmetic on Numeric values promotes
+// its operands to.
+type NumericType int
 
-func addToNumericInt(a, b Numeric) Numeric {
-	switch b.(type) {
-	case nil:
-		return a
-	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
-		return numericToInt64(a) + numericToInt64(b)
-	case float32, float64, Decimal, *Decimal:
-		return numericToFloat64(a) + numericToFloat64(b)
-	default:
-		panic(fmt.Sprintf("invalid value type: %T", b))
-	}
-}
+const (
+	NumericInt64 NumericType = 1 + iota
+	NumericUint64
+	NumericDecimal
+	NumericFloat64
+)
+
+var (
+	ErrNumericType     = errors.New("invalid numeric type")
+	ErrNumericOverflow = errors.New("numeric overflow")
+	ErrNumericInexact  = errors.New("numeric conversion loses precision")
+	ErrDivisionByZero  = errors.New("division by zero")
+	ErrNotANumber      = errors.New("not a number")
+)
 
-func addToNumericUint(a, b Numeric) Numeric {
-	switch b.(type) {
+// NumericTypeOf returns the type v is promoted to, or 0 for nil.
+func NumericTypeOf(v Numeric) (NumericType, error) {
+	switch v.(type) {
 	case nil:
-		return a
+		return 0, nil
 	case int, int8, int16, int32, int64:
-		return numericToInt64(a) + numericToInt64(b)
+		return NumericInt64, nil
 	case uint, uint8, uint16, uint32, uint64:
-		return numericToUint64(a) + numericToUint64(b)
-	case float32, float64, Decimal, *Decimal:
-		return numericToFloat64(a) + numericToFloat64(b)
-	default:
-		panic(fmt.Sprintf("invalid value type: %T", b))
+		return NumericUint64, nil
+	case Decimal, *Decimal:
+		return NumericDecimal, nil
+	case float32, float64:
+		return NumericFloat64, nil
 	}
+	return 0, fmt.Errorf("%w: %T", ErrNumericType, v)
 }
 
-func timeToNumeric(t time.Time) Numeric {
-	if t.IsZero() {
-		return nil
-	}
-	if t.Nanosecond() == 0 {
-		return t.Unix()
+// ConvertNumeric converts v to the given type. It fails with
+// ErrNumericOverflow if v is out of range and with ErrNumericInexact if a
+// fractional part would be dropped.
+func ConvertNumeric(v Numeric, to NumericType) (Numeric, error) {
+	switch to {
+	case NumericInt64:
+		return NumericToInt64(v)
+	case NumericUint64:
+		return NumericToUint64(v)
+	case NumericDecimal:
+		return NumericToDecimal(v)
+	case NumericFloat64:
+		return NumericToFloat64(v)
 	}
-	return float64(t.UnixNano()) / 1e9
+	return nil, fmt.Errorf("%w: %d", ErrNumericType, to)
 }
 
-func numericToTime(v Numeric) time.Time {
-	switch v.(type) {
-	case nil:
-		return time.Time{}
-	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
-		return intToTime(numericToInt64(v))
-	case float32, float64, Decimal, *Decimal:
-		return floatToTime(numericToFloat64(v))
-	default:
-		panic("unsupported type")
+// NumericToInt64 converts v to an int64.
+func NumericToInt64(v Numeric) (int64, error) {
+	x, err := numericToBig(v)
+	if err != nil {
+		return 0, err
 	}
+	if !x.IsInt64() {
+		return 0, ErrNumericOverflow
+	}
+	return x.Int64(), nil
 }
 
-func numericToDuration(v Numeric) time.Duration {
-	switch v.(type) {
-	case nil:
-		return 0
-	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
-		return time.Duration(numericToInt64(v)) * time.Second
-	case float32, float64, Decimal, *Decimal:
-		return floatToDuration(numericToFloat64(v))
-	default:
-		panic("unsupported type")
+// NumericToUint64 converts v to a uint64.
+func NumericToUint64(v Numeric) (uint64, error) {
+	x, err := numericToBig(v)
+	if err != nil {
+		return 0, err
+	}
+	if !x.IsUint64() {
+		return 0, ErrNumericOverflow
 	}
+	return x.Uint64(), nil
 }
 
-func numericToInt64(v Numeric) int64 {
-	switch i := v.(type) {
+// NumericToFloat64 converts v to the closest float64.
+func NumericToFloat64(v Numeric) (float64, error) {
+	switch f := v.(type) {
 	case nil:
-		return 0
+		return 0, nil
 	case int:
-		return int64(i)
+		return float64(f), nil
 	case int8:
-		return int64(i)
+		return float64(f), nil
 	case int16:
-		return int64(i)
+		return float64(f), nil
 	case int32:
-		return int64(i)
+		return float64(f), nil
 	case int64:
-		return i
+		return float64(f), nil
 	case uint:
-		return int64(i)
+		return float64(f), nil
 	case uint8:
-		return int64(i)
+		return float64(f), nil
 	case uint16:
-		return int64(i)
+		return float64(f), nil
 	case uint32:
-		return int64(i)
+		return float64(f), nil
 	case uint64:
-		return int64(i)
+		return float64(f), nil
 	case float32:
-		return int64(i)
+		return float64(f), nil
 	case float64:
-		return int64(i)
+		return f, nil
 	case Decimal:
-		return int64(i.Int())
+		return f.Float(), nil
 	case *Decimal:
-		return int64(i.Int())
-	default:
-		panic(fmt.Sprintf("invalid value type: %T", i))
+		return f.Float(), nil
 	}
+	return 0, fmt.Errorf("%w: %T", ErrNumericType, v)
 }
 
-func numericToUint64(v Numeric) uint64 {
-	switch i := v.(type) {
-	case nil:
-		return 0
-	case int:
-		return uint64(i)
-	case int8:
-		return uint64(i)
-	case int16:
-		return uint64(i)
-	case int32:
-		return uint64(i)
-	case int64:
-		return uint64(i)
-	case uint:
-		return uint64(i)
-	case uint8:
-		return uint64(i)
-	case uint16:
-		return uint64(i)
-	case uint32:
-		return uint64(i)
-	case uint64:
-		return i
-	case float32:
-		return uint64(i)
-	case float64:
-		return uint64(i)
+// NumericToDecimal converts v to a Decimal. Floats are converted from their
+// shortest decimal representation.
+func NumericToDecimal(v Numeric) (Decimal, error) {
+	switch d := v.(type) {
 	case Decimal:
-		return uint64(i.Int())
+		return d, nil
 	case *Decimal:
-		return uint64(i.Int())
-	default:
-		panic(fmt.Sprintf("invalid value type: %T", i))
+		return *d, nil
+	case float32, float64:
+		f, _ := NumericToFloat64(d)
+		if math.IsNaN(f) {
+			return Decimal{}, ErrNotANumber
+		}
+		if math.IsInf(f, 0) {
+			return Decimal{}, ErrNumericOverflow
+		}
+		bits := 64
+		if _, ok := d.(float32); ok {
+			bits = 32
+		}
+		n, err := ParseDecimal(strconv.FormatFloat(f, 'g', -1, bits))
+		if errors.Is(err, ErrDecimalOverflow) {
+			return Decimal{}, ErrNumericOverflow
+		}
+		return n, err
+	}
+	t, err := NumericTypeOf(v)
+	if err != nil {
+		return Decimal{}, err
+	}
+	if t == 0 {
+		return Decimal{}, nil
+	}
+	x, _ := numericToBig(v)
+	n, err := decimalFromBig(x, 0)
+	if err != nil {
+		return Decimal{}, ErrNumericOverflow
 	}
+	return n, nil
 }
 
-func numericToFloat64(v Numeric) float64 {
-	switch f := v.(type) {
+// AddNumeric returns a + b in the promoted type of a and b.
+func AddNumeric(a, b Numeric) (Numeric, error) {
+	return arith(a, b, '+')
+}
+
+// SubNumeric returns a - b in the promoted type of a and b.
+func SubNumeric(a, b Numeric) (Numeric, error) {
+	return arith(a, b, '-')
+}
+
+// MulNumeric returns a * b in the promoted type of a and b.
+func MulNumeric(a, b Numeric) (Numeric, error) {
+	return arith(a, b, '*')
+}
+
+// DivNumeric returns a / b. Integer quotients that are not whole numbers and
+// quotients of decimals are returned as float64.
+func DivNumeric(a, b Numeric) (Numeric, error) {
+	return arith(a, b, '/')
+}
+
+// CompareNumeric compares a and b exactly and returns -1, 0 or +1.
+func CompareNumeric(a, b Numeric) (int, error) {
+	ta, err := NumericTypeOf(a)
+	if err != nil {
+		return 0, err
+	}
+	tb, err := NumericTypeOf(b)
+	if err != nil {
+		return 0, err
+	}
+	switch promote(ta, tb) {
+	case 0, NumericInt64, NumericUint64:
+		x, _ := numericToBig(a)
+		y, _ := numericToBig(b)
+		return x.Cmp(y), nil
+	case NumericDecimal:
+		x, err := NumericToDecimal(a)
+		if err != nil {
+			return 0, err
+		}
+		y, err := NumericToDecimal(b)
+		if err != nil {
+			return 0, err
+		}
+		return x.Cmp(y), nil
+	}
+	x, err := numericToBigFloat(a)
+	if err != nil {
+		return 0, err
+	}
+	y, err := numericToBigFloat(b)
+	if err != nil {
+		return 0, err
+	}
+	return x.Cmp(y), nil
+}
+
+func arith(a, b Numeric, op byte) (Numeric, error) {
+	ta, err := NumericTypeOf(a)
+	if err != nil {
+		return nil, err
+	}
+	tb, err := NumericTypeOf(b)
+	if err != nil {
+		return nil, err
+	}
+	if ta == 0 && tb == 0 {
+		return nil, nil
+	}
+	switch promote(ta, tb) {
+	case NumericInt64, NumericUint64:
+		return arithInt(a, b, op, ta, tb)
+	case NumericDecimal:
+		if op == '/' {
+			break
+		}
+		x, err := NumericToDecimal(a)
+		if err != nil {
+			return nil, err
+		}
+		y, err := NumericToDecimal(b)
+		if err != nil {
+			return nil, err
+		}
+		var d Decimal
+		switch op {
+		case '+':
+			d, err = x.Add(y)
+		case '-':
+			d, err = x.Sub(y)
+		case '*':
+			d, err = x.Mul(y)
+		}
+		if errors.Is(err, ErrDecimalOverflow) {
+			return nil, ErrNumericOverflow
+		}
+		return d, err
+	}
+	x, _ := NumericToFloat64(a)
+	y, _ := NumericToFloat64(b)
+	if op == '/' && y == 0 {
+		return nil, ErrDivisionByZero
+	}
+	f := applyFloat(x, y, op)
+	if math.IsInf(f, 0) && !math.IsInf(x, 0) && !math.IsInf(y, 0) {
+		return nil, ErrNumericOverflow
+	}
+	return f, nil
+}
+
+// arithInt applies op to integers. The result is a uint64 when both operands
+// are unsigned, an int64 when both are signed and, for mixed operands, an
+// int64 or a uint64 if it does not fit.
+func arithInt(a, b Numeric, op byte, ta, tb NumericType) (Numeric, error) {
+	signed := ta != NumericUint64 && tb != NumericUint64
+	unsigned := ta != NumericInt64 && tb != NumericInt64
+	x, _ := numericToBig(a)
+	y, _ := numericToBig(b)
+	z := new(big.Int)
+	switch op {
+	case '+':
+		z.Add(x, y)
+	case '-':
+		z.Sub(x, y)
+	case '*':
+		z.Mul(x, y)
+	case '/':
+		if y.Sign() == 0 {
+			return nil, ErrDivisionByZero
+		}
+		if _, r := z.QuoRem(x, y, new(big.Int)); r.Sign() != 0 {
+			fx, _ := new(big.Float).SetInt(x).Float64()
+			fy, _ := new(big.Float).SetInt(y).Float64()
+			return fx / fy, nil
+		}
+	}
+	switch {
+	case unsigned && z.IsUint64():
+		return z.Uint64(), nil
+	case !unsigned && z.IsInt64():
+		return z.Int64(), nil
+	case !unsigned && !signed && z.IsUint64():
+		return z.Uint64(), nil
+	}
+	return nil, ErrNumericOverflow
+}
+
+func applyFloat(x, y float64, op byte) float64 {
+	switch op {
+	case '+':
+		return x + y
+	case '-':
+		return x - y
+	case '*':
+		return x * y
+	}
+	return x / y
+}
+
+// numericToBig returns the integer value of v.
+func numericToBig(v Numeric) (*big.Int, error) {
+	switch i := v.(type) {
 	case nil:
-		return 0
+		return new(big.Int), nil
 	case int:
-		return float64(f)
+		return big.NewInt(int64(i)), nil
 	case int8:
-		return float64(f)
+		return big.NewInt(int64(i)), nil
 	case int16:
-		return float64(f)
+		return big.NewInt(int64(i)), nil
 	case int32:
-		return float64(f)
+		return big.NewInt(int64(i)), nil
 	case int64:
-		return float64(f)
+		return big.NewInt(i), nil
 	case uint:
-		return float64(f)
+		return new(big.Int).SetUint64(uint64(i)), nil
 	case uint8:
-		return float64(f)
+		return new(big.Int).SetUint64(uint64(i)), nil
 	case uint16:
-		return float64(f)
+		return new(big.Int).SetUint64(uint64(i)), nil
 	case uint32:
-		return float64(f)
+		return new(big.Int).SetUint64(uint64(i)), nil
 	case uint64:
-		return float64(f)
-	case float32:
-		return float64(f)
-	case float64:
-		return f
-	case Decimal:
-		return f.Float()
-	case *Decimal:
-		return f.Float()
-	default:
-		panic(fmt.Sprintf("invalid value type: %T", f))
+		return new(big.Int).SetUint64(i), nil
+	case float32, float64:
+		f, _ := NumericToFloat64(i)
+		if math.IsNaN(f) {
+			return nil, ErrNotANumber
+		}
+		if math.IsInf(f, 0) {
+			return nil, ErrNumericOverflow
+		}
+		if f != math.Trunc(f) {
+			return nil, ErrNumericInexact
+		}
+		x, _ := big.NewFloat(f).Int(nil)
+		return x, nil
+	case Decimal, *Decimal:
+		d, _ := NumericToDecimal(i)
+		d = d.Normalize()
+		if d[0] < 0 {
+			return nil, ErrNumericInexact
+		}
+		if d[0] > 400 {
+			return nil, ErrNumericOverflow
+		}
+		return new(big.Int).Mul(big.NewInt(int64(d[1])), bigPow10(d[0])), nil
 	}
+	return nil, fmt.Errorf("%w: %T", ErrNumericType, v)
+}
+
+func numericToBigFloat(v Numeric) (*big.Float, error) {
+	switch f := v.(type) {
+	case float32, float64:
+		x, _ := NumericToFloat64(f)
+		if math.IsNaN(x) {
+			return nil, ErrNotANumber
+		}
+		return big.NewFloat(x), nil
+	case Decimal, *Decimal:
+		d, _ := NumericToDecimal(f)
+		x, _, err := big.ParseFloat(d.String(), 10, 256, big.ToNearestEven)
+		return x, err
+	}
+	x, err := numericToBig(v)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Float).SetInt(x), nil
+}
+
+// promote returns the wider of two numeric types.
+func promote(a, b NumericType) NumericType {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func timeToNumeric(t time.Time) Numeric {
+	if t.IsZero() {
+		return nil
+	}
+	if t.Nanosecond() == 0 {
+		return t.Unix()
+	}
+	return float64(t.UnixNano()) / 1e9
+}
+
+func numericToTime(v Numeric) time.Time {
+	if t, _ := NumericTypeOf(v); t == NumericInt64 || t == NumericUint64 {
+		if i, err := NumericToInt64(v); err == nil {
+			return intToTime(i)
+		}
+	}
+	if v == nil {
+		return time.Time{}
+	}
+	return floatToTime(numericToFloat64(v))
+}
+
+func numericToDuration(v Numeric) time.Duration {
+	if t, _ := NumericTypeOf(v); t == NumericInt64 || t == NumericUint64 {
+		if i, err := NumericToInt64(v); err == nil {
+			return time.Duration(i) * time.Second
+		}
+	}
+	return floatToDuration(numericToFloat64(v))
+}
+
+// numericToFloat64 is NumericToFloat64 for values known to be numbers.
+func numericToFloat64(v Numeric) float64 {
+	f, _ := NumericToFloat64(v)
+	return f
 }
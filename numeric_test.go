@@ -0,0 +1,67 @@
+package msgtypes
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestNumericArithmetic(t *testing.T) {
+	for _, c := range []struct {
+		op   func(a, b Numeric) (Numeric, error)
+		a, b Numeric
+		want Numeric
+		err  error
+	}{
+		{AddNumeric, 1, int8(2), int64(3), nil},
+		{AddNumeric, uint(1), uint32(2), uint64(3), nil},
+		{AddNumeric, nil, 2.5, 2.5, nil},
+		{AddNumeric, int64(math.MaxInt64), 1, nil, ErrNumericOverflow},
+		{AddNumeric, uint64(math.MaxUint64), -1, uint64(math.MaxUint64 - 1), nil},
+		{SubNumeric, uint(1), uint(2), nil, ErrNumericOverflow},
+		{SubNumeric, NewDecimal(-2, 1234), 1, NewDecimal(-2, 1134), nil},
+		{MulNumeric, NewDecimal(-1, 15), NewDecimal(-1, 2), NewDecimal(-2, 30), nil},
+		{MulNumeric, math.MaxFloat64, 2.0, nil, ErrNumericOverflow},
+		{DivNumeric, 6, 3, int64(2), nil},
+		{DivNumeric, 1, 4, 0.25, nil},
+		{DivNumeric, 1, 0, nil, ErrDivisionByZero},
+		{AddNumeric, "1", 2, nil, ErrNumericType},
+	} {
+		got, err := c.op(c.a, c.b)
+		if !errors.Is(err, c.err) || err == nil && got != c.want {
+			t.Errorf("(%v, %v): got %#v %v, want %#v %v", c.a, c.b, got, err, c.want, c.err)
+		}
+	}
+}
+
+func TestNumericConversion(t *testing.T) {
+	if _, err := ConvertNumeric(uint64(math.MaxUint64), NumericInt64); !errors.Is(err, ErrNumericOverflow) {
+		t.Fatalf("got %v, want %v", err, ErrNumericOverflow)
+	}
+	if _, err := ConvertNumeric(1.5, NumericInt64); !errors.Is(err, ErrNumericInexact) {
+		t.Fatalf("got %v, want %v", err, ErrNumericInexact)
+	}
+	if v, err := ConvertNumeric(0.1, NumericDecimal); err != nil || v != NewDecimal(-1, 1) {
+		t.Fatalf("got %v %v", v, err)
+	}
+	if v, err := ConvertNumeric(NewDecimal(2, 5), NumericUint64); err != nil || v != uint64(500) {
+		t.Fatalf("got %v %v", v, err)
+	}
+
+	for _, c := range []struct {
+		a, b Numeric
+		want int
+	}{
+		{int64(-1), uint64(math.MaxUint64), -1},
+		{NewDecimal(-1, 1), 0.1, -1},
+		{NewDecimal(-2, 50), 0.5, 0},
+		{uint8(3), 2.5, 1},
+	} {
+		if got, err := CompareNumeric(c.a, c.b); err != nil || got != c.want {
+			t.Errorf("CompareNumeric(%v, %v) = %d %v, want %d", c.a, c.b, got, err, c.want)
+		}
+	}
+	if _, err := CompareNumeric(math.NaN(), 1); !errors.Is(err, ErrNotANumber) {
+		t.Fatalf("got %v, want %v", err, ErrNotANumber)
+	}
+}
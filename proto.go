@@ -0,0 +1,143 @@
+package msgtypes
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/flywave/go-pbf"
+)
+
+var (
+	ErrProtoTruncated = fmt.Errorf("%w: truncated protobuf field", ErrMalformedPack)
+	ErrProtoVarint    = fmt.Errorf("%w: protobuf varint overflows", ErrMalformedPack)
+	ErrProtoWireType  = fmt.Errorf("%w: unexpected protobuf wire type", ErrMalformedPack)
+	ErrProtoLength    = fmt.Errorf("%w: invalid protobuf length", ErrMalformedPack)
+)
+
+// ProtoError reports where decoding a PROTO pack failed. Offset is the
+// position of the field in the input; Err is one of the ErrProto errors or
+// an error decoding the field value.
+type ProtoError struct {
+	Offset int
+	Tag    pbf.TagType
+	Err    error
+}
+
+func (e *ProtoError) Error() string {
+	return fmt.Sprintf("proto: field %d at offset %d: %v", e.Tag, e.Offset, e.Err)
+}
+
+func (e *ProtoError) Unwrap() error {
+	return e.Err
+}
+
+// protoReader reads protobuf fields from buf without ever reading past its
+// end. base is the offset of buf in the whole input, for error reporting.
+type protoReader struct {
+	buf  []byte
+	pos  int
+	base int
+}
+
+func (r *protoReader) done() bool {
+	return r.pos >= len(r.buf)
+}
+
+func (r *protoReader) varint() (uint64, error) {
+	v, n := binary.Uvarint(r.buf[r.pos:])
+	switch {
+	case n == 0:
+		return 0, ErrProtoTruncated
+	case n < 0:
+		return 0, ErrProtoVarint
+	}
+	r.pos += n
+	return v, nil
+}
+
+func (r *protoReader) tag() (pbf.TagType, pbf.WireType, error) {
+	key, err := r.varint()
+	if err != nil {
+		return 0, 0, err
+	}
+	if key>>3 == 0 || key>>3 > math.MaxInt32 {
+		return 0, 0, ErrProtoWireType
+	}
+	return pbf.TagType(key >> 3), pbf.WireType(key & 0x7), nil
+}
+
+func (r *protoReader) double() (float64, error) {
+	if len(r.buf)-r.pos < 8 {
+		return 0, ErrProtoTruncated
+	}
+	v := binary.LittleEndian.Uint64(r.buf[r.pos:])
+	r.pos += 8
+	return math.Float64frombits(v), nil
+}
+
+func (r *protoReader) bytes() ([]byte, error) {
+	n, err := r.varint()
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(len(r.buf)-r.pos) {
+		return nil, ErrProtoTruncated
+	}
+	b := r.buf[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return b, nil
+}
+
+func (r *protoReader) message() (*protoReader, error) {
+	b, err := r.bytes()
+	if err != nil {
+		return nil, err
+	}
+	return &protoReader{buf: b, base: r.base + r.pos - len(b)}, nil
+}
+
+// skip skips a field of an unknown tag. Groups are not supported.
+func (r *protoReader) skip(wire pbf.WireType) error {
+	var n int
+	switch wire {
+	case pbf.Varint:
+		_, err := r.varint()
+		return err
+	case pbf.Bytes:
+		_, err := r.bytes()
+		return err
+	case pbf.Fixed64:
+		n = 8
+	case pbf.Fixed32:
+		n = 4
+	default:
+		return ErrProtoWireType
+	}
+	if len(r.buf)-r.pos < n {
+		return ErrProtoTruncated
+	}
+	r.pos += n
+	return nil
+}
+
+// fields calls field for every field of r, which must consume the field
+// value or return ErrProtoWireType for an unexpected wire type. Fields of
+// unknown tags are skipped by field calling r.skip. Errors are returned as
+// ProtoError.
+func (r *protoReader) fields(field func(tag pbf.TagType, wire pbf.WireType) error) error {
+	for !r.done() {
+		offset := r.base + r.pos
+		tag, wire, err := r.tag()
+		if err == nil {
+			err = field(tag, wire)
+		}
+		if err != nil {
+			if _, ok := err.(*ProtoError); ok {
+				return err
+			}
+			return &ProtoError{Offset: offset, Tag: tag, Err: err}
+		}
+	}
+	return nil
+}
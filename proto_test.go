@@ -0,0 +1,246 @@
+package msgtypes
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/flywave/go-pbf"
+	"github.com/flywave/msgtypes/internal/senmlpb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func TestProtoSchema(t *testing.T) {
+	fields := (&senmlpb.Record{}).ProtoReflect().Descriptor().Fields()
+	for name, tag := range map[protoreflect.Name]pbf.TagType{
+		"base_name": BaseNameTag, "base_time": BaseTimeTag, "base_unit": BaseUnitTag,
+		"base_version": BaseVersionTag, "base_value": BaseValueTag, "base_sum": BaseSumTag,
+		"name": NameTag, "unit": UnitTag, "time": TimeTag, "update_time": UpdateTimeTag,
+		"value": ValueTag, "string_value": StringValueTag, "data_value": DataValueTag,
+		"bool_value": BoolValueTag, "sum": SumTag, "vector_value": VectorValueTag,
+		"enum_value": EnumValueTag, "extensions": ExtensionTag, "link": LinkTag,
+	} {
+		f := fields.ByName(name)
+		if f == nil || f.Number() != protoreflect.FieldNumber(tag) {
+			t.Errorf("field %s does not have number %d", name, tag)
+		}
+	}
+	if n := fields.Len(); n != 19 {
+		t.Errorf("senml.proto has %d Record fields", n)
+	}
+	if reserved := (&senmlpb.Record{}).ProtoReflect().Descriptor().ReservedRanges(); !reserved.Has(protoreflect.FieldNumber(PackedEnumValueTag)) {
+		t.Errorf("field %d is not reserved", PackedEnumValueTag)
+	}
+}
+
+func TestProtoLegacyEnum(t *testing.T) {
+	w := pbf.NewWriter()
+	w.WriteMessage(RecordsTag, func(w *pbf.Writer) {
+		w.WriteString(NameTag, "state")
+		w.WritePackedString(PackedEnumValueTag, []string{"on", "off"})
+	})
+	p, err := Decode(w.Finish(), PROTO)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p.Records[0].EnumValue; got == nil || !reflect.DeepEqual(*got, []string{"on", "off"}) {
+		t.Fatalf("got %v, want [on off]", got)
+	}
+
+	// A packed string longer than the field is truncated input.
+	msg := []byte{0x0a, 0x05, 0x8a, 0x01, 0x02, 0x05, 0x6f}
+	var perr *ProtoError
+	if _, err := Decode(msg, PROTO); !errors.Is(err, ErrProtoTruncated) || !errors.As(err, &perr) {
+		t.Fatalf("got %v, want %v", err, ErrProtoTruncated)
+	}
+}
+
+func TestProtoCompatibility(t *testing.T) {
+	v, s := 22.5, 100.0
+	vs, vd, vb := "text", []byte{0, 'd', 0xff}, false
+	vv, ve := []float64{1, 2.5}, []string{"on", "off"}
+	p := Pack{Records: []Record{
+		{BaseName: "dev/", BaseTime: 1.7e9, BaseUnit: "Cel", BaseVersion: 10, BaseValue: 20, BaseSum: 50,
+			Name: "temp", Time: -5, UpdateTime: 60, Value: &v},
+		{Name: "energy", Unit: "J", Sum: &s},
+		{Name: "label", StringValue: &vs, Extensions: map[string]interface{}{"lqi": 7.0, "tag": "x", "ok": true}},
+		{Name: "blob", DataValue: vd},
+		{Name: "flag", BoolValue: &vb},
+		{Name: "vec", VectorValue: &vv},
+		{Name: "state", EnumValue: &ve},
+	}}
+	b, err := Encode(p, PROTO)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pb senmlpb.Pack
+	if err := proto.Unmarshal(b, &pb); err != nil {
+		t.Fatal(err)
+	}
+	r := pb.Records
+	if len(r) != len(p.Records) || r[0].BaseName != "dev/" || r[0].BaseVersion != 10 || r[0].GetValue() != v ||
+		r[0].UpdateTime != 60 || r[1].GetSum() != s || r[2].GetStringValue() != vs || !bytes.Equal(r[3].GetDataValue(), vd) ||
+		r[4].BoolValue == nil || r[4].GetBoolValue() || !reflect.DeepEqual(r[5].VectorValue, vv) ||
+		!reflect.DeepEqual(r[6].EnumValue, ve) {
+		t.Fatalf("unexpected message %v", &pb)
+	}
+	if ext := r[2].Extensions; len(ext) != 3 || ext[0].Label != "lqi" || ext[0].GetNumber() != 7 ||
+		ext[1].Label != "ok" || !ext[1].GetBool() || ext[2].GetString_() != "x" {
+		t.Fatalf("unexpected extensions %v", ext)
+	}
+
+	// Decode what generated code writes, which packs vv and repeats ve.
+	b, err = proto.Marshal(&pb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Decode(b, PROTO)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Records, p.Records) {
+		t.Fatalf("got %+v, want %+v", got.Records, p.Records)
+	}
+}
+
+func TestProtoSize(t *testing.T) {
+	v := 21.5
+	b, err := Encode(Pack{Records: []Record{{Name: "temp", Value: &v}}}, PROTO)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Records tag and length, name tag, length and text, value tag and double.
+	if want := 2 + 6 + 9; len(b) != want {
+		t.Fatalf("encoded %d bytes, want %d", len(b), want)
+	}
+}
+
+func BenchmarkProtoSize(b *testing.B) {
+	v := 21.5
+	p := Pack{Records: make([]Record, 100)}
+	for i := range p.Records {
+		p.Records[i] = Record{Name: "temp", Time: float64(i), Value: &v}
+	}
+	p.Records[0].BaseName = "urn:dev:mac:0024befffe804ff1:"
+	p.Records[0].BaseTime = 1.7e9
+	p.Records[0].BaseUnit = "Cel"
+	var n int
+	for i := 0; i < b.N; i++ {
+		buf, err := Encode(p, PROTO)
+		if err != nil {
+			b.Fatal(err)
+		}
+		n = len(buf)
+	}
+	b.ReportMetric(float64(n)/float64(len(p.Records)), "bytes/record")
+}
+
+func TestProtoMalformed(t *testing.T) {
+	v := 1.5
+	valid, err := Encode(Pack{Records: []Record{{Name: "a", Value: &v}}}, PROTO)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// decoders decode msg whole and with a streaming Decoder.
+	decoders := map[string]func(msg []byte) (Pack, error){
+		"Decode": func(msg []byte) (Pack, error) {
+			return Decode(msg, PROTO)
+		},
+		"Decoder": func(msg []byte) (Pack, error) {
+			var p Pack
+			dec, err := NewDecoder(bytes.NewReader(msg), PROTO)
+			if err != nil {
+				return Pack{}, err
+			}
+			for {
+				r, err := dec.Next()
+				if err == io.EOF {
+					return p, nil
+				}
+				if err != nil {
+					return Pack{}, err
+				}
+				p.Records = append(p.Records, r)
+			}
+		},
+	}
+	for name, decode := range decoders {
+		for i := 1; i < len(valid); i++ {
+			var perr *ProtoError
+			if _, err := decode(valid[:i]); !errors.Is(err, ErrProtoTruncated) || !errors.As(err, &perr) {
+				t.Errorf("%s: truncated to %d bytes: got %v", name, i, err)
+			}
+		}
+	}
+	for _, c := range []struct {
+		name string
+		msg  []byte
+		want error
+	}{
+		{"truncated varint", []byte{0x0a, 0x80}, ErrProtoTruncated},
+		{"overflowing varint", []byte{0x10, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}, ErrProtoVarint},
+		{"bad length", []byte{0x0a, 0x7f, 0x3a, 0x01, 0x61}, ErrProtoTruncated},
+		{"wire type mismatch", []byte{0x0a, 0x02, 0x39, 0x01}, ErrProtoWireType},
+		{"group", []byte{0x0b}, ErrProtoWireType},
+		{"packed vector length", []byte{0x0a, 0x05, 0x82, 0x01, 0x02, 0x00, 0x00}, ErrProtoLength},
+	} {
+		offset := -1
+		for _, name := range []string{"Decode", "Decoder"} {
+			_, err := decoders[name](c.msg)
+			var perr *ProtoError
+			if !errors.Is(err, c.want) || !errors.As(err, &perr) {
+				t.Errorf("%s: %s: got %v, want %v", name, c.name, err, c.want)
+				continue
+			}
+			if offset >= 0 && perr.Offset != offset {
+				t.Errorf("%s: %s: error at offset %d, want %d", name, c.name, perr.Offset, offset)
+			}
+			offset = perr.Offset
+		}
+	}
+
+	// Unknown fields of every wire type are skipped.
+	msg := append([]byte{0x10, 0x96, 0x01, 0x19, 0, 0, 0, 0, 0, 0, 0, 0, 0x1d, 0, 0, 0, 0}, valid...)
+	rec := valid[2:]
+	msg = append(msg, 0x0a, byte(len(rec)+3), 0xf8, 0x01, 0x05)
+	msg = append(msg, rec...)
+	for name, decode := range decoders {
+		p, err := decode(msg)
+		if err != nil || len(p.Records) != 2 || *p.Records[1].Value != v {
+			t.Fatalf("%s: got %+v %v", name, p, err)
+		}
+	}
+}
+
+func FuzzDecodeProto(f *testing.F) {
+	v, s := 22.5, 1.0
+	vv, ve := []float64{1, 2}, []string{"a"}
+	for _, p := range []Pack{
+		{Records: []Record{{BaseName: "dev/", BaseTime: 1.7e9, Name: "t", Value: &v}}},
+		{Records: []Record{{Name: "s", Sum: &s, Extensions: map[string]interface{}{"x": []interface{}{1.0}}}}},
+		{Records: []Record{{Name: "vv", VectorValue: &vv}, {Name: "ve", EnumValue: &ve}}},
+	} {
+		b, err := Encode(p, PROTO)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(b)
+	}
+	f.Fuzz(func(t *testing.T, msg []byte) {
+		p, err := Decode(msg, PROTO)
+		if err != nil {
+			return
+		}
+		b, err := Encode(p, PROTO)
+		if err != nil {
+			t.Fatal(err)
+		}
+		q, err := Decode(b, PROTO)
+		if err != nil || len(q.Records) != len(p.Records) {
+			t.Fatalf("re-encoded pack decodes to %d records, %v", len(q.Records), err)
+		}
+	})
+}
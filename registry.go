@@ -0,0 +1,470 @@
+package msgtypes
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var ErrUnitRegistration = errors.New("invalid unit registration")
+
+// UnitInfo describes a registered unit. Primary is empty for primary units;
+// a value v in a secondary unit is v*Num/Den + Offset in its Primary unit.
+// A unit that is not recommended may name the Replacement unit to use
+// instead, in which v is v*ReplacementScale. ReplacementNum/ReplacementDen is
+// the same scale as an exact fraction, used for decimal values; both are zero
+// when the scale is irrational, and decimal values then fall back to floats.
+type UnitInfo struct {
+	Symbol      Unit
+	Description string
+	Recommended bool
+	Dimension   Dimension
+	Primary     Unit
+	Num, Den    int64
+	Offset      float64
+
+	Replacement                    Unit
+	ReplacementScale               float64
+	ReplacementNum, ReplacementDen int64
+}
+
+func (i UnitInfo) secondary() secondaryUnit {
+	if i.Primary == "" {
+		return secondaryUnit{i.Symbol, 1, 1, 0}
+	}
+	return secondaryUnit{i.Primary, i.Num, i.Den, i.Offset}
+}
+
+// UnitRegistry holds the units known to validation, dimensional analysis and
+// conversion. It is safe for concurrent use.
+type UnitRegistry struct {
+	mu    sync.RWMutex
+	units map[Unit]UnitInfo
+}
+
+// DefaultUnits is the registry used by the package level functions and by
+// options that leave their registry nil.
+var DefaultUnits = NewUnitRegistry()
+
+// NewUnitRegistry returns a registry holding the units of RFC 8428, RFC 8798
+// and the CoRE-1 secondary units.
+func NewUnitRegistry() *UnitRegistry {
+	g := &UnitRegistry{units: make(map[Unit]UnitInfo)}
+	for u, d := range unitDimensions {
+		_, deprecated := unitReplacements[u]
+		g.units[u] = UnitInfo{
+			Symbol:           u,
+			Description:      unitDescriptions[u],
+			Recommended:      !deprecated,
+			Dimension:        d,
+			Replacement:      unitReplacements[u].unit,
+			ReplacementScale: unitReplacements[u].scale,
+			ReplacementNum:   unitReplacements[u].num,
+			ReplacementDen:   unitReplacements[u].den,
+		}
+	}
+	for u, s := range secondaryUnits {
+		g.units[u] = UnitInfo{
+			Symbol:      u,
+			Description: unitDescriptions[u],
+			Recommended: true,
+			Dimension:   unitDimensions[s.primary],
+			Primary:     s.primary,
+			Num:         s.num,
+			Den:         s.den,
+			Offset:      s.offset,
+		}
+	}
+	return g
+}
+
+func unitRegistry(g *UnitRegistry) *UnitRegistry {
+	if g == nil {
+		return DefaultUnits
+	}
+	return g
+}
+
+// RegisterUnit adds or replaces a unit in DefaultUnits.
+func RegisterUnit(info UnitInfo) error {
+	return DefaultUnits.Register(info)
+}
+
+// Recommended reports whether u is registered in DefaultUnits and
+// recommended for use.
+func (u Unit) Recommended() bool {
+	info, ok := DefaultUnits.Lookup(u)
+	return ok && info.Recommended
+}
+
+// LookupUnit returns the unit registered in DefaultUnits under u.
+func LookupUnit(u Unit) (UnitInfo, bool) {
+	return DefaultUnits.Lookup(u)
+}
+
+// Register adds info to the registry, replacing any unit with the same
+// symbol. A secondary unit must refer to a registered primary unit, whose
+// dimension it takes; zero Num and Den default to 1.
+func (g *UnitRegistry) Register(info UnitInfo) error {
+	if info.Symbol == "" {
+		return fmt.Errorf("%w: empty symbol", ErrUnitRegistration)
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if info.Replacement != "" {
+		if _, ok := g.units[info.Replacement]; !ok || info.Replacement == info.Symbol {
+			return fmt.Errorf("%w: unknown replacement %q", ErrUnitRegistration, info.Replacement)
+		}
+		if info.ReplacementNum < 0 || info.ReplacementDen < 0 || (info.ReplacementNum == 0) != (info.ReplacementDen == 0) {
+			return fmt.Errorf("%w: %q has a bad replacement scale", ErrUnitRegistration, info.Symbol)
+		}
+		if info.ReplacementScale == 0 {
+			if info.ReplacementDen == 0 {
+				info.ReplacementNum, info.ReplacementDen = 1, 1
+			}
+			info.ReplacementScale = float64(info.ReplacementNum) / float64(info.ReplacementDen)
+		}
+	}
+	if info.Primary != "" {
+		p, ok := g.units[info.Primary]
+		if !ok || p.Primary != "" || info.Primary == info.Symbol {
+			return fmt.Errorf("%w: %q is not a primary unit", ErrUnitRegistration, info.Primary)
+		}
+		for _, s := range g.units {
+			if s.Primary == info.Symbol {
+				return fmt.Errorf("%w: %q is the primary unit of %q", ErrUnitRegistration, info.Symbol, s.Symbol)
+			}
+		}
+		if info.Num == 0 && info.Den == 0 {
+			info.Num, info.Den = 1, 1
+		}
+		if info.Num <= 0 || info.Den <= 0 {
+			return fmt.Errorf("%w: %q has a bad scale", ErrUnitRegistration, info.Symbol)
+		}
+		info.Dimension = p.Dimension
+	} else {
+		info.Num, info.Den, info.Offset = 0, 0, 0
+		for _, s := range g.units {
+			if s.Primary == info.Symbol {
+				s.Dimension = info.Dimension
+				g.units[s.Symbol] = s
+			}
+		}
+	}
+	g.units[info.Symbol] = info
+	return nil
+}
+
+// Lookup returns the unit registered under u.
+func (g *UnitRegistry) Lookup(u Unit) (UnitInfo, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	info, ok := g.units[u]
+	return info, ok
+}
+
+// Units returns every registered unit, ordered by symbol.
+func (g *UnitRegistry) Units() []UnitInfo {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	units := make([]UnitInfo, 0, len(g.units))
+	for _, info := range g.units {
+		units = append(units, info)
+	}
+	sort.Slice(units, func(i, j int) bool { return units[i].Symbol < units[j].Symbol })
+	return units
+}
+
+func (g *UnitRegistry) secondary(u Unit) secondaryUnit {
+	if info, ok := g.Lookup(u); ok {
+		return info.secondary()
+	}
+	return secondaryUnit{u, 1, 1, 0}
+}
+
+func (g *UnitRegistry) dimension(u Unit) (Dimension, bool) {
+	info, ok := g.Lookup(u)
+	return info.Dimension, ok
+}
+
+// LoadCSV registers the units of an IANA "SenML Units" or "Secondary Units"
+// registry export in CSV format. The kind of registry is recognized from the
+// header row; rows noting "not recommended" are marked as such.
+func (g *UnitRegistry) LoadCSV(r io.Reader) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return err
+	}
+	for i := range header {
+		header[i] = registryKey(header[i])
+	}
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		fields := make(map[string]string)
+		for i, v := range row {
+			if i < len(header) {
+				fields[header[i]] = strings.TrimSpace(v)
+			}
+		}
+		if err := g.loadRecord(fields); err != nil {
+			return err
+		}
+	}
+}
+
+// LoadXML registers the units of an IANA SenML registry export in XML format.
+// Every record element of the document is loaded, so the file holding both
+// the units and the secondary units registries can be read at once.
+func (g *UnitRegistry) LoadXML(r io.Reader) error {
+	type field struct {
+		XMLName xml.Name
+		Value   string `xml:",chardata"`
+	}
+	type record struct {
+		Fields []field `xml:",any"`
+	}
+	d := xml.NewDecoder(r)
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "record" {
+			continue
+		}
+		var rec record
+		if err := d.DecodeElement(&rec, &start); err != nil {
+			return err
+		}
+		fields := make(map[string]string)
+		for _, f := range rec.Fields {
+			fields[registryKey(f.XMLName.Local)] = strings.TrimSpace(f.Value)
+		}
+		if err := g.loadRecord(fields); err != nil {
+			return err
+		}
+	}
+}
+
+func registryKey(s string) string {
+	return strings.NewReplacer(" ", "", "-", "", "_", "").Replace(strings.ToLower(strings.TrimSpace(s)))
+}
+
+// loadRecord registers one registry record, keyed by registryKey. Units
+// already known keep their dimension.
+func (g *UnitRegistry) loadRecord(fields map[string]string) error {
+	symbol, primary := "", fields["senmlunit"]
+	for _, k := range []string{"secondaryunit", "symbol", "value", "name"} {
+		if symbol = fields[k]; symbol != "" {
+			break
+		}
+	}
+	recommended := true
+	if strings.HasSuffix(symbol, "*") {
+		symbol, recommended = strings.TrimSuffix(symbol, "*"), false
+	}
+	for _, v := range fields {
+		if strings.Contains(strings.ToLower(v), "not recommended") {
+			recommended = false
+		}
+	}
+	if symbol == "" {
+		return nil
+	}
+	info, ok := g.Lookup(Unit(symbol))
+	if !ok {
+		info = UnitInfo{Symbol: Unit(symbol)}
+		info.Dimension, _ = g.Dimension(info.Symbol)
+	}
+	info.Description = fields["description"]
+	info.Recommended = recommended
+	if primary != "" {
+		info.Primary = Unit(primary)
+		num, den, err := parseScale(fields["scale"])
+		if err != nil {
+			return fmt.Errorf("%w: %q: %v", ErrUnitRegistration, symbol, err)
+		}
+		info.Num, info.Den = num, den
+		info.Offset = 0
+		if s := fields["offset"]; s != "" {
+			if info.Offset, err = strconv.ParseFloat(s, 64); err != nil {
+				return fmt.Errorf("%w: %q: %v", ErrUnitRegistration, symbol, err)
+			}
+		}
+	}
+	return g.Register(info)
+}
+
+// parseScale parses a scale such as "3600", "0.001" or "1/3.6" into a
+// fraction num/den.
+func parseScale(s string) (num, den int64, err error) {
+	if s == "" {
+		return 1, 1, nil
+	}
+	n, d := s, "1"
+	if i := strings.IndexByte(s, '/'); i >= 0 {
+		n, d = s[:i], s[i+1:]
+	}
+	rn, ok := new(big.Rat).SetString(strings.TrimSpace(n))
+	rd, ok2 := new(big.Rat).SetString(strings.TrimSpace(d))
+	if !ok || !ok2 || rn.Sign() <= 0 || rd.Sign() <= 0 {
+		return 0, 0, fmt.Errorf("bad scale %q", s)
+	}
+	q := new(big.Rat).Quo(rn, rd)
+	if !q.Num().IsInt64() || !q.Denom().IsInt64() {
+		return 0, 0, fmt.Errorf("scale %q out of range", s)
+	}
+	return q.Num().Int64(), q.Denom().Int64(), nil
+}
+
+// unitReplacements lists the built-in units that are not recommended,
+// together with the recommended unit and the scale into it, exactly as
+// num/den where the scale is rational.
+var unitReplacements = map[Unit]struct {
+	unit     Unit
+	scale    float64
+	num, den int64
+}{
+	Gram:           {Kilogram, 0.001, 1, 1000},
+	Liter:          {CubicMeter, 0.001, 1, 1000},
+	LiterPerSecond: {CubicMeterPerSecond, 0.001, 1, 1000},
+	Bel:            {Decibel, 10, 10, 1},
+	Ratio2:         {Ratio, 0.01, 1, 100},
+	RPM:            {Rate, 1.0 / 60, 1, 60},
+	HeartRate:      {Rate, 1.0 / 60, 1, 60},
+	HeartBeats:     {Count, 1, 1, 1},
+	Degree:         {Radian, math.Pi / 180, 0, 0},
+}
+
+var unitDescriptions = map[Unit]string{
+	None:                    "dimensionless",
+	Meter:                   "meter",
+	Kilogram:                "kilogram",
+	Gram:                    "gram",
+	Second:                  "second",
+	Ampere:                  "ampere",
+	Kelvin:                  "kelvin",
+	Candela:                 "candela",
+	Mole:                    "mole",
+	Hertz:                   "hertz",
+	Radian:                  "radian",
+	Steradian:               "steradian",
+	Newton:                  "newton",
+	Pascal:                  "pascal",
+	Joule:                   "joule",
+	Watt:                    "watt",
+	Coulomb:                 "coulomb",
+	Volt:                    "volt",
+	Farad:                   "farad",
+	Ohm:                     "ohm",
+	Siemens:                 "siemens",
+	Weber:                   "weber",
+	Tesla:                   "tesla",
+	Henry:                   "henry",
+	Celsius:                 "degrees Celsius",
+	Lumen:                   "lumen",
+	Lux:                     "lux",
+	Becquerel:               "becquerel",
+	Gray:                    "gray",
+	Sievert:                 "sievert",
+	Katal:                   "katal",
+	SquareMeter:             "square meter (area)",
+	CubicMeter:              "cubic meter (volume)",
+	Liter:                   "liter (volume)",
+	MeterPerSecond:          "meter per second (velocity)",
+	MeterPerSquareSecond:    "meter per square second (acceleration)",
+	CubicMeterPerSecond:     "cubic meter per second (flow rate)",
+	LiterPerSecond:          "liter per second (flow rate)",
+	WattPerSquareMeter:      "watt per square meter (irradiance)",
+	CandelaPerSquareMeter:   "candela per square meter (luminance)",
+	Bit:                     "bit (information content)",
+	BitPerSecond:            "bit per second (data rate)",
+	Latitude:                "degrees latitude",
+	Longitude:               "degrees longitude",
+	PH:                      "pH value (acidity; logarithmic quantity)",
+	Decibel:                 "decibel (logarithmic quantity)",
+	DBW:                     "decibel relative to 1 W (power level)",
+	Bel:                     "bel (sound pressure level; logarithmic quantity)",
+	Count:                   "1 (counter value)",
+	Ratio:                   "1 (ratio, e.g., value of a switch)",
+	Ratio2:                  "1 (ratio, e.g., value of a switch)",
+	RelativeHumidityPercent: "percentage (relative humidity)",
+	RemainingBatteryPercent: "percentage (remaining battery energy level)",
+	RemainingBatterySeconds: "seconds (remaining battery energy level)",
+	Rate:                    "1 per second (event rate)",
+	RPM:                     "1 per minute (event rate, \"rpm\")",
+	HeartRate:               "1 per minute (heart rate in beats per minute)",
+	HeartBeats:              "1 (cumulative number of heart beats)",
+	Conductivity:            "siemens per meter (conductivity)",
+
+	Byte:                     "byte (information content)",
+	VoltAmpere:               "volt-ampere (apparent power)",
+	VoltAmpereSecond:         "volt-ampere second (apparent energy)",
+	VoltAmpereReactive:       "volt-ampere reactive (reactive power)",
+	VoltAmpereReactiveSecond: "volt-ampere-reactive second (reactive energy)",
+	JoulePerMeter:            "joule per meter (energy per distance)",
+	KilogramPerCubicMeter:    "kilogram per cubic meter (mass density, mass concentration)",
+	Degree:                   "degree (angle)",
+
+	NephelometricTurbidityUnit: "nephelometric turbidity unit",
+
+	Millisecond:            "millisecond",
+	Minute:                 "minute",
+	Hour:                   "hour",
+	Megahertz:              "megahertz",
+	Kilowatt:               "kilowatt",
+	KilovoltAmpere:         "kilovolt-ampere",
+	Kilovar:                "kilovar",
+	AmpereHour:             "ampere-hour",
+	WattHour:               "watt-hour",
+	KilowattHour:           "kilowatt-hour",
+	VarHour:                "var-hour",
+	KilovarHour:            "kilovar-hour",
+	KilovoltAmpereHour:     "kilovolt-ampere-hour",
+	WattHourPerKilometer:   "watt-hour per kilometer",
+	Kibibyte:               "kibibyte",
+	Gigabyte:               "gigabyte",
+	MegabitPerSecond:       "megabit per second",
+	BytePerSecond:          "byte per second",
+	MegabytePerSecond:      "megabyte per second",
+	Millivolt:              "millivolt",
+	Milliampere:            "milliampere",
+	DecibelMilliwatt:       "decibel (milliwatt)",
+	MicrogramPerCubicMeter: "microgram per cubic meter",
+	MillimeterPerHour:      "millimeter per hour",
+	MeterPerHour:           "meter per hour",
+	PartsPerMillion:        "parts per million",
+	Percent:                "percent",
+	Permille:               "permille",
+	Hectopascal:            "hectopascal",
+	Millimeter:             "millimeter",
+	Centimeter:             "centimeter",
+	Kilometer:              "kilometer",
+	KilometerPerHour:       "kilometer per hour",
+	PartsPerBillion:        "parts per billion",
+	PartsPerTrillion:       "parts per trillion",
+	VoltAmpereHour:         "volt-ampere-hour",
+	Milligram:              "milligram per liter",
+	Microgram:              "microgram per liter",
+	GramPerLiter:           "gram per liter",
+}
@@ -0,0 +1,90 @@
+package msgtypes
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestUnitRegistry(t *testing.T) {
+	g := NewUnitRegistry()
+	if info, ok := g.Lookup(KilowattHour); !ok || info.Primary != Joule || info.Dimension != energyDim || !info.Recommended {
+		t.Fatalf("unexpected %+v", info)
+	}
+	if info, _ := g.Lookup(Gram); info.Recommended {
+		t.Fatal("g should not be recommended")
+	}
+
+	if err := g.Register(UnitInfo{Symbol: "mi", Description: "mile", Primary: Meter, Num: 1609344, Den: 1000}); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := g.Convert(2, "mi", Kilometer); err != nil || v != 3.218688 {
+		t.Fatalf("got %v %v", v, err)
+	}
+	if !g.Compatible("mi", Meter) || Unit("mi").Compatible(Meter) {
+		t.Fatal("custom unit should only be known to its registry")
+	}
+	for _, info := range []UnitInfo{{}, {Symbol: "x", Primary: "nope"}, {Symbol: "x", Primary: KilowattHour}, {Symbol: Joule, Primary: Watt}} {
+		if err := g.Register(info); !errors.Is(err, ErrUnitRegistration) {
+			t.Errorf("Register(%+v) = %v", info, err)
+		}
+	}
+
+	csvUnits := "Symbol,Description,Type,Reference,Notes\n" +
+		"m,meter,float,[RFC8428],\n" +
+		"gon,gradian,float,[example],NOT RECOMMENDED\n" +
+		"m/s3,meter per cubic second (jerk),float,[example],\n"
+	if err := g.LoadCSV(strings.NewReader(csvUnits)); err != nil {
+		t.Fatal(err)
+	}
+	if info, ok := g.Lookup("m/s3"); !ok || info.Dimension != dim(1, 0, -3, 0, 0, 0, 0) || !info.Recommended {
+		t.Fatalf("unexpected %+v", info)
+	}
+	if info, _ := g.Lookup("gon"); info.Recommended || info.Description != "gradian" {
+		t.Fatalf("unexpected %+v", info)
+	}
+
+	csvSecondary := "Secondary Unit,Description,SenML Unit,Scale,Offset,Reference\n" +
+		"km/h,kilometer per hour,m/s,1/3.6,0,[RFC8798]\n" +
+		"degF,degree Fahrenheit,K,5/9,255.372222,[example]\n"
+	if err := g.LoadCSV(strings.NewReader(csvSecondary)); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := g.Lookup("degF"); info.Num != 5 || info.Den != 9 || info.Dimension != temperatureDim {
+		t.Fatalf("unexpected %+v", info)
+	}
+
+	xmlUnits := `<?xml version="1.0"?>
+<registry xmlns="http://www.iana.org/assignments" id="senml">
+  <registry id="senml-units">
+    <record><symbol>ft</symbol><description>foot</description></record>
+  </registry>
+  <registry id="secondary-units">
+    <record>
+      <secondary-unit>in</secondary-unit>
+      <description>inch</description>
+      <senml-unit>m</senml-unit>
+      <scale>0.0254</scale>
+    </record>
+  </registry>
+</registry>`
+	if err := g.LoadXML(strings.NewReader(xmlUnits)); err != nil {
+		t.Fatal(err)
+	}
+	if info, ok := g.Lookup("in"); !ok || info.Primary != Meter || info.Num != 127 || info.Den != 5000 {
+		t.Fatalf("unexpected %+v", info)
+	}
+	if _, ok := g.Lookup("ft"); !ok {
+		t.Fatal("ft not loaded")
+	}
+
+	v := 1.0
+	p := Pack{Records: []Record{{Name: "a", Unit: "in", Value: &v}}}
+	if err := (ValidationOptions{KnownUnits: true, Units: g}).Validate(p); err != nil {
+		t.Fatal(err)
+	}
+	n, err := NormalizeOptions{PrimaryUnits: true, Units: g}.Normalize(p)
+	if err != nil || n.Records[0].Unit != "m" || *n.Records[0].Value != 0.0254 {
+		t.Fatalf("got %+v %v", n, err)
+	}
+}
@@ -0,0 +1,621 @@
+package msgtypes
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"math"
+
+	"github.com/flywave/go-pbf"
+	"github.com/fxamacker/cbor"
+)
+
+var (
+	ErrMalformedPack = errors.New("malformed pack")
+	ErrTooDeep       = errors.New("cbor nesting too deep")
+)
+
+const maxCBORDepth = 32
+
+// Decoder reads the records of a pack one at a time from an input stream.
+// Base fields are tracked across calls to Next and every record is validated
+// and resolved as it is read, so a pack never has to be held in memory as a
+// whole. Unlike Normalize, the records are returned in input order.
+type Decoder struct {
+	format Format
+	r      *bufio.Reader
+	json   *json.Decoder
+	xml    *xml.Decoder
+	v      validator
+	res    resolver
+
+	started bool
+	done    bool
+	indef   bool
+	remain  uint64
+	offset  int
+	err     error
+}
+
+// NewDecoder returns a Decoder that reads a pack in the given format from r.
+// It returns ErrUnsupportedFormat for formats that cannot be streamed, EXI
+// and SenSMLEXI included.
+func NewDecoder(r io.Reader, format Format) (*Decoder, error) {
+	if !streamable(format) {
+		return nil, ErrUnsupportedFormat
+	}
+	d := &Decoder{format: format.base()}
+	switch d.format {
+	case JSON:
+		d.json = json.NewDecoder(r)
+	case XML:
+		d.xml = xml.NewDecoder(r)
+	default:
+		d.r = bufio.NewReader(r)
+	}
+	return d, nil
+}
+
+// streamable reports whether Decoder and Encoder support format.
+func streamable(format Format) bool {
+	switch format.base() {
+	case JSON, XML, CBOR, PROTO:
+		return true
+	}
+	return false
+}
+
+// SetValidationOptions changes how the records read by Next are validated.
+func (d *Decoder) SetValidationOptions(opts ValidationOptions) {
+	d.v.opts = opts
+}
+
+// Next returns the next record of the pack with the base fields in effect
+// applied, as Normalize does. It returns io.EOF once the pack has been read
+// completely.
+func (d *Decoder) Next() (Record, error) {
+	if d.err != nil {
+		return Record{}, d.err
+	}
+	if d.done {
+		return Record{}, io.EOF
+	}
+	var r Record
+	var err error
+	switch d.format {
+	case JSON:
+		r, err = d.nextJSON()
+	case XML:
+		r, err = d.nextXML()
+	case CBOR:
+		r, err = d.nextCBOR()
+	case PROTO:
+		r, err = d.nextProto()
+	default:
+		err = ErrUnsupportedFormat
+	}
+	if err == nil {
+		err = d.v.validate(r)
+	}
+	if err == io.EOF {
+		d.done = true
+		return Record{}, err
+	}
+	if err != nil {
+		d.err = err
+		return Record{}, err
+	}
+	return d.res.resolve(r), nil
+}
+
+func (d *Decoder) nextJSON() (Record, error) {
+	if !d.started {
+		t, err := d.json.Token()
+		if err != nil {
+			return Record{}, unexpectedEOF(err)
+		}
+		if t != json.Delim('[') {
+			return Record{}, ErrMalformedPack
+		}
+		d.started = true
+	}
+	if !d.json.More() {
+		t, err := d.json.Token()
+		if err != nil {
+			return Record{}, unexpectedEOF(err)
+		}
+		if t != json.Delim(']') {
+			return Record{}, ErrMalformedPack
+		}
+		return Record{}, io.EOF
+	}
+	var r Record
+	if err := d.json.Decode(&r); err != nil {
+		return Record{}, unexpectedEOF(err)
+	}
+	return r, nil
+}
+
+func (d *Decoder) nextXML() (Record, error) {
+	for {
+		t, err := d.xml.Token()
+		if err != nil {
+			return Record{}, unexpectedEOF(err)
+		}
+		switch e := t.(type) {
+		case xml.StartElement:
+			switch {
+			case !d.started && e.Name.Local == "sensml":
+				d.started = true
+			case d.started && e.Name.Local == "senml":
+				var r Record
+				if err := d.xml.DecodeElement(&r, &e); err != nil {
+					return Record{}, unexpectedEOF(err)
+				}
+				return r, nil
+			default:
+				return Record{}, ErrMalformedPack
+			}
+		case xml.EndElement:
+			if d.started && e.Name.Local == "sensml" {
+				return Record{}, io.EOF
+			}
+		}
+	}
+}
+
+func (d *Decoder) nextCBOR() (Record, error) {
+	if !d.started {
+		b, err := d.r.ReadByte()
+		if err != nil {
+			return Record{}, unexpectedEOF(err)
+		}
+		if b>>5 != 4 {
+			return Record{}, ErrMalformedPack
+		}
+		if b&0x1f == 31 {
+			d.indef = true
+		} else if d.remain, err = readCBORArg(d.r, b&0x1f); err != nil {
+			return Record{}, err
+		}
+		d.started = true
+	}
+	if d.indef {
+		b, err := d.r.Peek(1)
+		if err != nil {
+			return Record{}, unexpectedEOF(err)
+		}
+		if b[0] == 0xff {
+			d.r.ReadByte()
+			return Record{}, io.EOF
+		}
+	} else {
+		if d.remain == 0 {
+			return Record{}, io.EOF
+		}
+		d.remain--
+	}
+	item, err := readCBORItem(d.r, nil, 0)
+	if err != nil {
+		return Record{}, err
+	}
+	var r Record
+	if err := cbor.Unmarshal(item, &r); err != nil {
+		return Record{}, err
+	}
+	return r, nil
+}
+
+func (d *Decoder) nextProto() (Record, error) {
+	for {
+		offset := d.offset
+		if _, err := d.r.Peek(1); err == io.EOF {
+			return Record{}, io.EOF
+		}
+		tag, wire, err := d.protoTag()
+		if err == nil {
+			var r Record
+			var ok bool
+			if r, ok, err = d.protoField(tag, wire); err == nil && ok {
+				return r, nil
+			}
+		}
+		if err == nil {
+			continue
+		}
+		if _, ok := err.(*ProtoError); ok || !errors.Is(err, ErrMalformedPack) {
+			return Record{}, err
+		}
+		return Record{}, &ProtoError{Offset: offset, Tag: tag, Err: err}
+	}
+}
+
+// protoField reads the value of a top-level field of a PROTO pack. ok is
+// true when it is a record; other fields are skipped.
+func (d *Decoder) protoField(tag pbf.TagType, wire pbf.WireType) (r Record, ok bool, err error) {
+	if wire != pbf.Bytes {
+		if tag == RecordsTag {
+			return Record{}, false, ErrProtoWireType
+		}
+		return Record{}, false, d.skipProto(wire)
+	}
+	n, err := d.protoVarint()
+	if err != nil {
+		return Record{}, false, err
+	}
+	if n > math.MaxInt32 {
+		return Record{}, false, ErrProtoLength
+	}
+	if tag != RecordsTag {
+		return Record{}, false, d.discard(int(n))
+	}
+	base := d.offset
+	buf, err := appendN(d.r, nil, n)
+	if err != nil {
+		return Record{}, false, protoTruncated(err)
+	}
+	d.offset += int(n)
+	err = decodeRecord(&protoReader{buf: buf, base: base}, &r)
+	return r, err == nil, err
+}
+
+// protoVarint reads a varint like protoReader.varint, counting the bytes
+// read in d.offset.
+func (d *Decoder) protoVarint() (uint64, error) {
+	var v uint64
+	for i := 0; i < binary.MaxVarintLen64; i++ {
+		b, err := d.r.ReadByte()
+		if err != nil {
+			return 0, protoTruncated(err)
+		}
+		d.offset++
+		if i == binary.MaxVarintLen64-1 && b > 1 {
+			return 0, ErrProtoVarint
+		}
+		v |= uint64(b&0x7f) << (7 * uint(i))
+		if b < 0x80 {
+			return v, nil
+		}
+	}
+	return 0, ErrProtoVarint
+}
+
+func (d *Decoder) protoTag() (pbf.TagType, pbf.WireType, error) {
+	key, err := d.protoVarint()
+	if err != nil {
+		return 0, 0, err
+	}
+	if key>>3 == 0 || key>>3 > math.MaxInt32 {
+		return 0, 0, ErrProtoWireType
+	}
+	return pbf.TagType(key >> 3), pbf.WireType(key & 0x7), nil
+}
+
+// skipProto skips a field of an unknown tag like protoReader.skip.
+func (d *Decoder) skipProto(wire pbf.WireType) error {
+	switch wire {
+	case pbf.Varint:
+		_, err := d.protoVarint()
+		return err
+	case pbf.Fixed64:
+		return d.discard(8)
+	case pbf.Fixed32:
+		return d.discard(4)
+	}
+	return ErrProtoWireType
+}
+
+func (d *Decoder) discard(n int) error {
+	m, err := d.r.Discard(n)
+	d.offset += m
+	return protoTruncated(err)
+}
+
+// protoTruncated turns the end of the input inside a field into
+// ErrProtoTruncated.
+func protoTruncated(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return ErrProtoTruncated
+	}
+	return err
+}
+
+// readCBORArg reads the argument of a CBOR head whose additional
+// information is ai.
+func readCBORArg(r *bufio.Reader, ai byte) (uint64, error) {
+	var n int
+	switch {
+	case ai < 24:
+		return uint64(ai), nil
+	case ai == 24:
+		n = 1
+	case ai == 25:
+		n = 2
+	case ai == 26:
+		n = 4
+	case ai == 27:
+		n = 8
+	default:
+		return 0, ErrMalformedPack
+	}
+	var v uint64
+	for i := 0; i < n; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, unexpectedEOF(err)
+		}
+		v = v<<8 | uint64(b)
+	}
+	return v, nil
+}
+
+// readCBORItem appends one complete CBOR data item read from r to buf.
+func readCBORItem(r *bufio.Reader, buf []byte, depth int) ([]byte, error) {
+	if depth > maxCBORDepth {
+		return nil, ErrTooDeep
+	}
+	b, err := r.ReadByte()
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	major, ai := b>>5, b&0x1f
+	if ai == 31 {
+		buf = append(buf, b)
+		switch major {
+		case 2, 3, 4, 5:
+		default:
+			return nil, ErrMalformedPack
+		}
+		for {
+			p, err := r.Peek(1)
+			if err != nil {
+				return nil, unexpectedEOF(err)
+			}
+			if p[0] == 0xff {
+				r.ReadByte()
+				return append(buf, 0xff), nil
+			}
+			if buf, err = readCBORItem(r, buf, depth+1); err != nil {
+				return nil, err
+			}
+			if major == 5 {
+				if buf, err = readCBORItem(r, buf, depth+1); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	arg, err := readCBORArg(r, ai)
+	if err != nil {
+		return nil, err
+	}
+	buf = append(buf, b)
+	for i := cborArgLen(ai) - 1; i >= 0; i-- {
+		buf = append(buf, byte(arg>>(8*uint(i))))
+	}
+	switch major {
+	case 2, 3:
+		if buf, err = appendN(r, buf, arg); err != nil {
+			return nil, err
+		}
+	case 4, 5:
+		if major == 5 {
+			arg *= 2
+		}
+		for i := uint64(0); i < arg; i++ {
+			if buf, err = readCBORItem(r, buf, depth+1); err != nil {
+				return nil, err
+			}
+		}
+	case 6:
+		return readCBORItem(r, buf, depth+1)
+	}
+	return buf, nil
+}
+
+// appendN appends exactly n bytes read from r to buf, growing buf only as
+// data actually arrives.
+func appendN(r io.Reader, buf []byte, n uint64) ([]byte, error) {
+	if n > math.MaxInt32 {
+		return nil, ErrMalformedPack
+	}
+	b := bytes.NewBuffer(buf)
+	if _, err := io.CopyN(b, r, int64(n)); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return b.Bytes(), nil
+}
+
+func cborArgLen(ai byte) int {
+	switch ai {
+	case 24:
+		return 1
+	case 25:
+		return 2
+	case 26:
+		return 4
+	case 27:
+		return 8
+	}
+	return 0
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+var ErrEncoderClosed = errors.New("encoder closed")
+
+// Encoder writes the records of a pack one at a time to an output stream.
+// JSON and XML packs are written as a single array or sensml document, CBOR
+// packs as an indefinite-length array and PROTO packs as a sequence of
+// length-delimited records. Base fields are only emitted when they change
+// the value already in effect, so callers may set them on every record.
+type Encoder struct {
+	w      io.Writer
+	format Format
+	n      int
+
+	started bool
+	closed  bool
+	err     error
+
+	bname  string
+	btime  float64
+	bunit  string
+	bver   uint
+	bvalue float64
+	bsum   float64
+}
+
+// NewEncoder returns an Encoder that writes a pack in the given format to w.
+// Close must be called to terminate the pack. It returns ErrUnsupportedFormat
+// for formats that cannot be streamed, EXI and SenSMLEXI included.
+func NewEncoder(w io.Writer, format Format) (*Encoder, error) {
+	if !streamable(format) {
+		return nil, ErrUnsupportedFormat
+	}
+	return &Encoder{w: w, format: format.base()}, nil
+}
+
+// Write encodes r as the next record of the pack.
+func (e *Encoder) Write(r Record) error {
+	if e.err != nil {
+		return e.err
+	}
+	if e.closed {
+		return ErrEncoderClosed
+	}
+	e.err = e.start()
+	if e.err == nil {
+		e.err = e.write(e.compact(r))
+	}
+	return e.err
+}
+
+// Close terminates the pack. It does not close the underlying writer.
+func (e *Encoder) Close() error {
+	if e.err != nil {
+		return e.err
+	}
+	if e.closed {
+		return nil
+	}
+	e.err = e.start()
+	if e.err != nil {
+		return e.err
+	}
+	e.closed = true
+	switch e.format {
+	case JSON:
+		_, e.err = io.WriteString(e.w, "]")
+	case XML:
+		_, e.err = io.WriteString(e.w, "</sensml>")
+	case CBOR:
+		_, e.err = e.w.Write([]byte{0xff})
+	}
+	return e.err
+}
+
+func (e *Encoder) start() error {
+	if e.started {
+		return nil
+	}
+	e.started = true
+	var err error
+	switch e.format {
+	case JSON:
+		_, err = io.WriteString(e.w, "[")
+	case XML:
+		_, err = io.WriteString(e.w, `<sensml xmlns="`+xmlns+`">`)
+	case CBOR:
+		_, err = e.w.Write([]byte{0x9f})
+	case PROTO:
+	default:
+		err = ErrUnsupportedFormat
+	}
+	return err
+}
+
+// compact drops base fields that repeat the value already in effect.
+func (e *Encoder) compact(r Record) Record {
+	if r.BaseName != "" {
+		if r.BaseName == e.bname {
+			r.BaseName = ""
+		} else {
+			e.bname = r.BaseName
+		}
+	}
+	if r.BaseTime != 0 {
+		if r.BaseTime == e.btime {
+			r.BaseTime = 0
+		} else {
+			e.btime = r.BaseTime
+		}
+	}
+	if r.BaseUnit != "" {
+		if r.BaseUnit == e.bunit {
+			r.BaseUnit = ""
+		} else {
+			e.bunit = r.BaseUnit
+		}
+	}
+	if r.BaseVersion != 0 {
+		if r.BaseVersion == e.bver {
+			r.BaseVersion = 0
+		} else {
+			e.bver = r.BaseVersion
+		}
+	}
+	if r.BaseValue != 0 {
+		if r.BaseValue == e.bvalue {
+			r.BaseValue = 0
+		} else {
+			e.bvalue = r.BaseValue
+		}
+	}
+	if r.BaseSum != 0 {
+		if r.BaseSum == e.bsum {
+			r.BaseSum = 0
+		} else {
+			e.bsum = r.BaseSum
+		}
+	}
+	return r
+}
+
+func (e *Encoder) write(r Record) error {
+	var b []byte
+	var err error
+	switch e.format {
+	case JSON:
+		if b, err = json.Marshal(r); err == nil && e.n > 0 {
+			b = append([]byte{','}, b...)
+		}
+	case XML:
+		b, err = xml.Marshal(r)
+	case CBOR:
+		b, err = cbor.Marshal(r, cbor.CanonicalEncOptions())
+	case PROTO:
+		w := pbf.NewWriter()
+		w.WriteMessage(RecordsTag, func(w *pbf.Writer) {
+			err = encodeRecord(w, &r)
+		})
+		b = w.Finish()
+	}
+	if err != nil {
+		return err
+	}
+	if _, err = e.w.Write(b); err != nil {
+		return err
+	}
+	e.n++
+	return nil
+}
@@ -0,0 +1,186 @@
+package msgtypes
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestDecoder(t *testing.T) {
+	v := 20.6
+	s := "on"
+	cv := []float64{36.5, 118.4}
+
+	p := Pack{Records: []Record{
+		{BaseName: "urn:dev:ow:10e2073a01080063:", Name: "temp", Value: &v},
+		{Name: "state", StringValue: &s},
+		{Name: "pos", VectorValue: &cv},
+	}}
+
+	want := resolve(p)
+	for _, f := range []Format{JSON, XML, CBOR, PROTO} {
+		data, err := Encode(p, f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dec, err := NewDecoder(bytes.NewReader(data), f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var n int
+		for {
+			r, err := dec.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("format %d: %v", f, err)
+			}
+			if r.Name != want.Records[n].Name || r.BaseName != "" {
+				t.Fatalf("format %d: got name %q, want %q", f, r.Name, want.Records[n].Name)
+			}
+			n++
+		}
+		if n != len(p.Records) {
+			t.Fatalf("format %d: got %d records, want %d", f, n, len(p.Records))
+		}
+	}
+}
+
+func TestDecoderIndefiniteCBOR(t *testing.T) {
+	// [_ {0: "a", 2: 1}, {0: "b", 2: 2}]
+	data := []byte{0x9f, 0xa2, 0x00, 0x61, 'a', 0x02, 0x01, 0xa2, 0x00, 0x61, 'b', 0x02, 0x02, 0xff}
+
+	dec, err := NewDecoder(bytes.NewReader(data), CBOR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for {
+		r, err := dec.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, r.Name)
+	}
+	if len(names) != 2 || names[0] != "a" || names[1] != "b" {
+		t.Fatalf("unexpected records %v", names)
+	}
+}
+
+func TestDecoderValidates(t *testing.T) {
+	dec, err := NewDecoder(bytes.NewReader([]byte(`[{"n":"a","v":1},{"n":"b"}]`)), JSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dec.Next(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dec.Next(); !errors.Is(err, ErrNoValues) {
+		t.Fatalf("got %v, want %v", err, ErrNoValues)
+	}
+}
+
+func TestDecoderResolves(t *testing.T) {
+	data := `[{"bn":"dev/","bt":100,"bu":"Cel","bv":10,"bs":5,"n":"a","v":1},{"n":"b","t":1,"v":2,"s":1}]`
+	dec, err := NewDecoder(bytes.NewReader([]byte(data)), JSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dec.Next(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := dec.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Name != "dev/b" || r.Time != 101 || r.Unit != "Cel" || *r.Value != 12 || *r.Sum != 6 {
+		t.Fatalf("unresolved record %+v", r)
+	}
+}
+
+func TestDecoderTruncated(t *testing.T) {
+	v := 1.0
+	data, _ := Encode(Pack{Records: []Record{{Name: "a", Value: &v}}}, CBOR)
+
+	dec, err := NewDecoder(bytes.NewReader(data[:len(data)-2]), CBOR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dec.Next(); err != io.ErrUnexpectedEOF {
+		t.Fatalf("got %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+}
+
+func TestEncoder(t *testing.T) {
+	v := 20.6
+	s := "on"
+
+	recs := []Record{
+		{BaseName: "dev:", BaseUnit: "Cel", BaseValue: 5, Name: "temp", Value: &v},
+		{BaseName: "dev:", BaseUnit: "Cel", BaseValue: 5, Name: "state", StringValue: &s},
+	}
+
+	for _, f := range []Format{JSON, XML, CBOR, PROTO} {
+		var buf bytes.Buffer
+		enc, err := NewEncoder(&buf, f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, r := range recs {
+			if err := enc.Write(r); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := enc.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		p, err := Decode(buf.Bytes(), f)
+		if err != nil {
+			t.Fatalf("format %d: %v", f, err)
+		}
+		if len(p.Records) != len(recs) {
+			t.Fatalf("format %d: got %d records, want %d", f, len(p.Records), len(recs))
+		}
+		if p.Records[1].BaseName != "" || p.Records[1].BaseUnit != "" || p.Records[1].BaseValue != 0 {
+			t.Fatalf("format %d: repeated base fields were emitted", f)
+		}
+		n, _ := Normalize(p)
+		if n.Records[1].Name != "dev:state" || n.Records[1].Unit != "Cel" || *n.Records[0].Value != 25.6 {
+			t.Fatalf("format %d: unexpected record %+v", f, n.Records[1])
+		}
+	}
+}
+
+func TestEncoderEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	enc, err := NewEncoder(&buf, JSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "[]" {
+		t.Fatalf("got %q", buf.String())
+	}
+	if err := enc.Write(Record{}); err != ErrEncoderClosed {
+		t.Fatalf("got %v, want %v", err, ErrEncoderClosed)
+	}
+}
+
+func TestStreamUnsupportedFormat(t *testing.T) {
+	for _, f := range []Format{EXI, SenSMLEXI, 0} {
+		if _, err := NewDecoder(bytes.NewReader(nil), f); !errors.Is(err, ErrUnsupportedFormat) {
+			t.Errorf("decoder %d: got %v, want %v", f, err, ErrUnsupportedFormat)
+		}
+		if _, err := NewEncoder(io.Discard, f); !errors.Is(err, ErrUnsupportedFormat) {
+			t.Errorf("encoder %d: got %v, want %v", f, err, ErrUnsupportedFormat)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package msgtypes
+
+// Kind is the type of the value of a record.
+type Kind int
+
+const (
+	FloatKind Kind = 1 + iota
+	StringKind
+	DataKind
+	BoolKind
+	VectorKind
+	EnumKind
+)
+
+var kindNames = map[Kind]string{
+	FloatKind:  "float",
+	StringKind: "string",
+	DataKind:   "data",
+	BoolKind:   "bool",
+	VectorKind: "vector",
+	EnumKind:   "enum",
+}
+
+func (k Kind) String() string {
+	if s, ok := kindNames[k]; ok {
+		return s
+	}
+	return "none"
+}
+
+// NewFloatRecord returns a record named name with the value v in unit.
+func NewFloatRecord(name string, v float64, unit Unit) Record {
+	r := Record{Name: name, Unit: string(unit)}
+	r.SetFloat(v)
+	return r
+}
+
+// NewStringRecord returns a record named name with the string value s.
+func NewStringRecord(name, s string) Record {
+	r := Record{Name: name}
+	r.SetString(s)
+	return r
+}
+
+// NewDataRecord returns a record named name with the data value d.
+func NewDataRecord(name string, d []byte) Record {
+	r := Record{Name: name}
+	r.SetData(d)
+	return r
+}
+
+// NewBoolRecord returns a record named name with the boolean value b.
+func NewBoolRecord(name string, b bool) Record {
+	r := Record{Name: name}
+	r.SetBool(b)
+	return r
+}
+
+// NewVectorRecord returns a record named name with the vector value v in
+// unit.
+func NewVectorRecord(name string, v []float64, unit Unit) Record {
+	r := Record{Name: name, Unit: string(unit)}
+	r.SetVector(v)
+	return r
+}
+
+// NewEnumRecord returns a record named name with the enumeration value e.
+func NewEnumRecord(name string, e []string) Record {
+	r := Record{Name: name}
+	r.SetEnum(e)
+	return r
+}
+
+// Kind returns the type of the value of r, or 0 if r has none. The sum is
+// not a value. For invalid records with several values the first of v, vs,
+// vd, vb, vv and ve wins.
+func (r *Record) Kind() Kind {
+	switch {
+	case r.Value != nil || r.DecimalValue != nil:
+		return FloatKind
+	case r.StringValue != nil:
+		return StringKind
+	case r.DataValue != nil:
+		return DataKind
+	case r.BoolValue != nil:
+		return BoolKind
+	case r.VectorValue != nil:
+		return VectorKind
+	case r.EnumValue != nil:
+		return EnumKind
+	}
+	return 0
+}
+
+// Any returns the value of r as float64, string, []byte, bool, []float64
+// or []string, or nil if r has none.
+func (r *Record) Any() interface{} {
+	switch r.Kind() {
+	case FloatKind:
+		v, _ := r.Float()
+		return v
+	case StringKind:
+		return *r.StringValue
+	case DataKind:
+		d, _ := r.Data()
+		return d
+	case BoolKind:
+		return *r.BoolValue
+	case VectorKind:
+		return *r.VectorValue
+	case EnumKind:
+		return *r.EnumValue
+	}
+	return nil
+}
+
+// Float returns the numeric value of r, falling back to the float form of
+// DecimalValue.
+func (r *Record) Float() (float64, bool) {
+	if v := r.floatValue(); v != nil {
+		return *v, true
+	}
+	return 0, false
+}
+
+// StringVal returns the string value of r.
+func (r *Record) StringVal() (string, bool) {
+	if r.StringValue == nil {
+		return "", false
+	}
+	return *r.StringValue, true
+}
+
+// Data returns the data value of r.
+func (r *Record) Data() ([]byte, bool) {
+	return r.DataValue, r.DataValue != nil
+}
+
+// Bool returns the boolean value of r.
+func (r *Record) Bool() (bool, bool) {
+	if r.BoolValue == nil {
+		return false, false
+	}
+	return *r.BoolValue, true
+}
+
+// Vector returns the vector value of r.
+func (r *Record) Vector() ([]float64, bool) {
+	if r.VectorValue == nil {
+		return nil, false
+	}
+	return *r.VectorValue, true
+}
+
+// Enum returns the enumeration value of r.
+func (r *Record) Enum() ([]string, bool) {
+	if r.EnumValue == nil {
+		return nil, false
+	}
+	return *r.EnumValue, true
+}
+
+// The setters replace the value of r, clearing the other value fields.
+
+// SetFloat sets the numeric value of r to v.
+func (r *Record) SetFloat(v float64) {
+	r.clearValue()
+	r.Value = &v
+}
+
+// SetString sets the string value of r to s.
+func (r *Record) SetString(s string) {
+	r.clearValue()
+	r.StringValue = &s
+}
+
+// SetData sets the data value of r to d. A nil d is stored as empty data.
+func (r *Record) SetData(d []byte) {
+	r.clearValue()
+	if d == nil {
+		d = []byte{}
+	}
+	r.DataValue = d
+}
+
+// SetBool sets the boolean value of r to b.
+func (r *Record) SetBool(b bool) {
+	r.clearValue()
+	r.BoolValue = &b
+}
+
+// SetVector sets the vector value of r to v.
+func (r *Record) SetVector(v []float64) {
+	r.clearValue()
+	r.VectorValue = &v
+}
+
+// SetEnum sets the enumeration value of r to e.
+func (r *Record) SetEnum(e []string) {
+	r.clearValue()
+	r.EnumValue = &e
+}
+
+func (r *Record) clearValue() {
+	r.Value = nil
+	r.DecimalValue = nil
+	r.StringValue = nil
+	r.DataValue = nil
+	r.BoolValue = nil
+	r.VectorValue = nil
+	r.EnumValue = nil
+}
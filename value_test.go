@@ -0,0 +1,60 @@
+package msgtypes
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRecordValues(t *testing.T) {
+	records := []struct {
+		record Record
+		kind   Kind
+		value  interface{}
+	}{
+		{NewFloatRecord("temp", 21.5, "Cel"), FloatKind, 21.5},
+		{NewStringRecord("state", "open"), StringKind, "open"},
+		{NewDataRecord("blob", []byte("hello")), DataKind, []byte("hello")},
+		{NewBoolRecord("door", true), BoolKind, true},
+		{NewVectorRecord("acc", []float64{0, -9.81, 0}, "m/s2"), VectorKind, []float64{0, -9.81, 0}},
+		{NewEnumRecord("mode", []string{"eco"}), EnumKind, []string{"eco"}},
+		{Record{Name: "none"}, 0, nil},
+	}
+	for _, c := range records {
+		if k := c.record.Kind(); k != c.kind {
+			t.Errorf("%s: kind %v, want %v", c.record.Name, k, c.kind)
+		}
+		if v := c.record.Any(); !reflect.DeepEqual(v, c.value) {
+			t.Errorf("%s: value %#v, want %#v", c.record.Name, v, c.value)
+		}
+		if c.kind != 0 {
+			if err := Validate(Pack{Records: []Record{c.record}}); err != nil {
+				t.Errorf("%s: %v", c.record.Name, err)
+			}
+		}
+	}
+
+	r := NewFloatRecord("x", 1, "")
+	d := NewDecimal(-1, 15)
+	r.DecimalValue = &d
+	r.Value = nil
+	if v, ok := r.Float(); !ok || v != 1.5 {
+		t.Fatalf("decimal float %v, %v", v, ok)
+	}
+	r.SetBool(false)
+	if r.Value != nil || r.DecimalValue != nil || r.Kind() != BoolKind {
+		t.Fatal("SetBool kept the numeric value")
+	}
+	if _, ok := r.Float(); ok {
+		t.Fatal("Float of a boolean record")
+	}
+	if b, ok := r.Bool(); !ok || b {
+		t.Fatalf("bool %v, %v", b, ok)
+	}
+	if _, ok := r.StringVal(); ok {
+		t.Fatal("StringVal of a boolean record")
+	}
+	r.SetString("open")
+	if s, ok := r.StringVal(); !ok || s != "open" || r.BoolValue != nil {
+		t.Fatalf("string %q, %v", s, ok)
+	}
+}
@@ -0,0 +1,166 @@
+package msgtypes
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// WatchdogEvent reports that a sensor went overdue or recovered.
+type WatchdogEvent struct {
+	Name string
+	// Overdue is true when the sensor missed the update promised by its
+	// update time, and false when a reading arrived after that.
+	Overdue bool
+	// Due is the time the missed reading was due.
+	Due time.Time
+	// Time is the clock time the sensor was found overdue, or the time of
+	// the reading it recovered with.
+	Time time.Time
+}
+
+// Watchdog detects stale sensors from the update time (ut) of their
+// records. Every observed record with an update time promises a new reading
+// for its name by its time plus ut; Check reports the names whose promise
+// has expired. A later record for the name clears the promise or replaces
+// it.
+//
+// The zero value is ready to use. Set the fields before the first call.
+type Watchdog struct {
+	// Now is the clock, time.Now if nil.
+	Now func() time.Time
+	// Notify, if set, is called for every event.
+	Notify func(WatchdogEvent)
+	// Events, if set, receives every event. Sends block, so the channel
+	// must be buffered or drained by another goroutine.
+	Events chan<- WatchdogEvent
+
+	mu      sync.Mutex
+	sensors map[string]*sensorState
+}
+
+type sensorState struct {
+	last    float64
+	due     time.Time
+	overdue bool
+}
+
+func (w *Watchdog) now() time.Time {
+	if w.Now == nil {
+		return time.Now()
+	}
+	return w.Now()
+}
+
+// Observe records the readings of p, which must be normalized.
+func (w *Watchdog) Observe(p Pack) {
+	var events []WatchdogEvent
+	w.mu.Lock()
+	if w.sensors == nil {
+		w.sensors = make(map[string]*sensorState)
+	}
+	for i := range p.Records {
+		if e, ok := w.observe(&p.Records[i]); ok {
+			events = append(events, e)
+		}
+	}
+	w.mu.Unlock()
+	w.emit(events)
+}
+
+func (w *Watchdog) observe(r *Record) (WatchdogEvent, bool) {
+	s, ok := w.sensors[r.Name]
+	if ok && r.Time < s.last {
+		return WatchdogEvent{}, false
+	}
+	var e WatchdogEvent
+	recovered := ok && s.overdue
+	if recovered {
+		e = WatchdogEvent{Name: r.Name, Due: s.due, Time: r.Timestamp()}
+	}
+	due, promised := r.NextUpdate()
+	if !promised {
+		delete(w.sensors, r.Name)
+		return e, recovered
+	}
+	w.sensors[r.Name] = &sensorState{last: r.Time, due: due}
+	return e, recovered
+}
+
+// Check reports the sensors that went overdue since the last call, and
+// returns the earliest time a watched sensor that is not yet overdue is
+// due. ok is false when there is none.
+func (w *Watchdog) Check() (next time.Time, ok bool) {
+	now := w.now()
+	var events []WatchdogEvent
+	w.mu.Lock()
+	for name, s := range w.sensors {
+		if s.overdue {
+			continue
+		}
+		if now.After(s.due) {
+			s.overdue = true
+			events = append(events, WatchdogEvent{Name: name, Overdue: true, Due: s.due, Time: now})
+			continue
+		}
+		if !ok || s.due.Before(next) {
+			next, ok = s.due, true
+		}
+	}
+	w.mu.Unlock()
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].Due.Equal(events[j].Due) {
+			return events[i].Due.Before(events[j].Due)
+		}
+		return events[i].Name < events[j].Name
+	})
+	w.emit(events)
+	return next, ok
+}
+
+// Overdue returns the names of the sensors currently overdue, sorted.
+func (w *Watchdog) Overdue() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var names []string
+	for name, s := range w.sensors {
+		if s.overdue {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Forget stops watching name.
+func (w *Watchdog) Forget(name string) {
+	w.mu.Lock()
+	delete(w.sensors, name)
+	w.mu.Unlock()
+}
+
+// Run calls Check every interval until ctx is done, and returns ctx.Err().
+func (w *Watchdog) Run(ctx context.Context, interval time.Duration) error {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+			w.Check()
+		}
+	}
+}
+
+func (w *Watchdog) emit(events []WatchdogEvent) {
+	for _, e := range events {
+		if w.Notify != nil {
+			w.Notify(e)
+		}
+		if w.Events != nil {
+			w.Events <- e
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package msgtypes
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWatchdog(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	now := start
+	events := make(chan WatchdogEvent, 8)
+	var notified []WatchdogEvent
+	w := &Watchdog{
+		Now:    func() time.Time { return now },
+		Notify: func(e WatchdogEvent) { notified = append(notified, e) },
+		Events: events,
+	}
+
+	v := 1.0
+	reading := func(name string, at time.Time, ut float64) Pack {
+		r := Record{Name: name, Value: &v, UpdateTime: ut}
+		r.SetTimestamp(at)
+		return Pack{Records: []Record{r}}
+	}
+	w.Observe(reading("temp", start, 60))
+	w.Observe(reading("hum", start, 30))
+	w.Observe(reading("door", start, 0))
+
+	if next, ok := w.Check(); !ok || !next.Equal(start.Add(30*time.Second)) {
+		t.Fatalf("next due %v, %v", next, ok)
+	}
+
+	now = start.Add(45 * time.Second)
+	w.Check()
+	want := WatchdogEvent{Name: "hum", Overdue: true, Due: start.Add(30 * time.Second), Time: now}
+	if e := <-events; e != want {
+		t.Fatalf("got %+v, want %+v", e, want)
+	}
+	if got := w.Overdue(); !reflect.DeepEqual(got, []string{"hum"}) {
+		t.Fatalf("overdue %v", got)
+	}
+
+	// Checking again does not report hum twice.
+	now = start.Add(90 * time.Second)
+	w.Check()
+	if e := <-events; e.Name != "temp" || !e.Overdue {
+		t.Fatalf("got %+v", e)
+	}
+
+	// An older reading does not recover a sensor.
+	w.Observe(reading("hum", start.Add(-time.Minute), 30))
+	w.Observe(reading("hum", now, 30))
+	want = WatchdogEvent{Name: "hum", Due: start.Add(30 * time.Second), Time: now}
+	if e := <-events; e != want {
+		t.Fatalf("got %+v, want %+v", e, want)
+	}
+	if got := w.Overdue(); !reflect.DeepEqual(got, []string{"temp"}) {
+		t.Fatalf("overdue %v", got)
+	}
+
+	w.Forget("temp")
+	if got := w.Overdue(); got != nil {
+		t.Fatalf("overdue %v", got)
+	}
+	if len(events) != 0 || len(notified) != 3 {
+		t.Fatalf("%d events left, %d notified", len(events), len(notified))
+	}
+}